@@ -6,6 +6,9 @@
 package cdceval
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
@@ -121,6 +124,10 @@ func (c *prevCol) ColName() tree.Name {
 	return c.name
 }
 
+func (c *prevCol) StableIdentity() string {
+	return fmt.Sprintf("col_%d", c.id)
+}
+
 func (c *prevCol) HasType() bool {
 	return true
 }
@@ -129,6 +136,18 @@ func (c *prevCol) GetType() *types.T {
 	return c.t
 }
 
+func (c *prevCol) TypeFamilyName() string {
+	return string(c.t.Family().Name())
+}
+
+func (c *prevCol) ArrayElementType() (*types.T, bool) {
+	return nil, false
+}
+
+func (c *prevCol) CollationName() (string, bool) {
+	return "", false
+}
+
 func (c *prevCol) ColumnDescDeepCopy() descpb.ColumnDescriptor {
 	return descpb.ColumnDescriptor{}
 }
@@ -177,6 +196,14 @@ func (c *prevCol) IsNullable() bool {
 	return true
 }
 
+func (c *prevCol) IsEffectivelyNotNull(desc catalog.TableDescriptor) bool {
+	return false
+}
+
+func (c *prevCol) IsFamilyPrimaryColumn(desc catalog.TableDescriptor) bool {
+	return false
+}
+
 func (c *prevCol) HasDefault() bool {
 	return false
 }
@@ -189,6 +216,16 @@ func (c *prevCol) GetDefaultExpr() string {
 	return ""
 }
 
+func (c *prevCol) DefaultExprReferencesSequence(seqID descpb.ID) bool {
+	return false
+}
+
+func (c *prevCol) DefaultExprIsConstant(
+	ctx context.Context, semaCtx *tree.SemaContext,
+) (bool, error) {
+	return true, nil
+}
+
 func (c *prevCol) HasOnUpdate() bool {
 	return false
 }
@@ -197,6 +234,14 @@ func (c *prevCol) GetOnUpdateExpr() string {
 	return ""
 }
 
+func (c *prevCol) OnUpdateExprTree() (tree.Expr, error) {
+	return nil, nil
+}
+
+func (c *prevCol) OnUpdateIsCurrentTimestamp() bool {
+	return false
+}
+
 func (c *prevCol) IsComputed() bool {
 	return false
 }
@@ -213,6 +258,10 @@ func (c *prevCol) IsExpressionIndexColumn() bool {
 	return false
 }
 
+func (c *prevCol) IsHashShardColumn(desc catalog.TableDescriptor) bool {
+	return false
+}
+
 func (c *prevCol) NumUsesSequences() int {
 	return 0
 }
@@ -269,10 +318,18 @@ func (c *prevCol) IsGeneratedByDefaultAsIdentity() bool {
 	return false
 }
 
+func (c *prevCol) IsOverridableIdentity() bool {
+	return false
+}
+
 func (c *prevCol) GetGeneratedAsIdentityType() catpb.GeneratedAsIdentityType {
 	return catpb.GeneratedAsIdentityType_NOT_IDENTITY_COLUMN
 }
 
+func (c *prevCol) GeneratedIdentitySequenceID() (descpb.ID, bool) {
+	return descpb.InvalidID, false
+}
+
 func (c *prevCol) HasGeneratedAsIdentitySequenceOption() bool {
 	return false
 }
@@ -287,6 +344,10 @@ func (c *prevCol) GetGeneratedAsIdentitySequenceOption(
 	return nil, errors.AssertionFailedf("unexpected call to GetGeneratedAsIdentitySequenceOption on cdc_prev")
 }
 
+func (c *prevCol) IdentitySequenceParams() (start, increment int64, ok bool, err error) {
+	return 0, 0, false, nil
+}
+
 func (c *prevCol) initColumnDescriptor() {
 	c.d = &descpb.ColumnDescriptor{
 		Name:         c.GetName(),
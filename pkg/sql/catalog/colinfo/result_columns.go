@@ -34,9 +34,27 @@ type ResultColumns []ResultColumn
 
 // ResultColumnsFromColumns converts []catalog.Column to []ResultColumn.
 func ResultColumnsFromColumns(tableID descpb.ID, columns []catalog.Column) ResultColumns {
-	return ResultColumnsFromColDescs(tableID, len(columns), func(i int) *descpb.ColumnDescriptor {
-		return columns[i].ColumnDesc()
-	})
+	cols := make(ResultColumns, len(columns))
+	for i, col := range columns {
+		cols[i] = ColumnToResultColumn(tableID, col)
+	}
+	return cols
+}
+
+// ColumnToResultColumn converts a catalog.Column of the table with the given ID
+// to a ResultColumn.
+func ColumnToResultColumn(tableID descpb.ID, col catalog.Column) ResultColumn {
+	typ := col.GetType()
+	if typ == nil {
+		panic(errors.AssertionFailedf("column %q has no type", col.GetName()))
+	}
+	return ResultColumn{
+		Name:           col.GetName(),
+		Typ:            typ,
+		Hidden:         col.IsHidden(),
+		TableID:        tableID,
+		PGAttributeNum: uint32(col.GetPGAttributeNum()),
+	}
 }
 
 // ResultColumnsFromColDescs is used by ResultColumnsFromColumns and by tests.
@@ -9,7 +9,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResultColumnsTypesEqual(t *testing.T) {
@@ -62,3 +65,40 @@ func TestResultColumnsTypesEqual(t *testing.T) {
 		})
 	}
 }
+
+// testColumn implements the subset of catalog.Column used to convert a column
+// to a ResultColumn, based on a column descriptor. The tabledesc
+// implementation can't be used here since tabledesc depends on colinfo.
+type testColumn struct {
+	catalog.Column
+	desc *descpb.ColumnDescriptor
+}
+
+func (c testColumn) GetName() string                          { return c.desc.Name }
+func (c testColumn) GetType() *types.T                        { return c.desc.Type }
+func (c testColumn) IsHidden() bool                           { return c.desc.Hidden }
+func (c testColumn) GetPGAttributeNum() descpb.PGAttributeNum { return c.desc.GetPGAttributeNum() }
+
+func TestColumnToResultColumn(t *testing.T) {
+	const tableID = 104
+	colDescs := []descpb.ColumnDescriptor{
+		{ID: 1, Name: "rowid", Type: types.Int, Hidden: true},
+		{ID: 2, Name: "s", Type: types.MakeVarChar(10), Nullable: true, PGAttributeNum: 5},
+		{ID: 3, Name: "d", Type: types.Decimal, Nullable: true},
+	}
+	expected := ResultColumns{
+		{Name: "rowid", Typ: types.Int, Hidden: true, TableID: tableID, PGAttributeNum: 1},
+		{Name: "s", Typ: types.MakeVarChar(10), TableID: tableID, PGAttributeNum: 5},
+		{Name: "d", Typ: types.Decimal, TableID: tableID, PGAttributeNum: 3},
+	}
+	columns := make([]catalog.Column, len(colDescs))
+	for i := range colDescs {
+		columns[i] = testColumn{desc: &colDescs[i]}
+		require.Equal(t, expected[i], ColumnToResultColumn(tableID, columns[i]))
+	}
+	require.Equal(t, expected, ResultColumnsFromColumns(tableID, columns))
+	// The conversion from column descriptors must agree.
+	require.Equal(t, expected, ResultColumnsFromColDescs(tableID, len(colDescs), func(i int) *descpb.ColumnDescriptor {
+		return &colDescs[i]
+	}))
+}
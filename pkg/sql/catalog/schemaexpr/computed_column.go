@@ -16,6 +16,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/cast"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/transform"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -176,6 +177,131 @@ func ValidateColumnHasNoDependents(desc catalog.TableDescriptor, col catalog.Col
 	return nil
 }
 
+// ComputeExprDependsOnVirtualColumn returns true iff col is a stored computed
+// column whose expression references a virtual column of desc. Such a column
+// is invalid, since stored computed columns can't depend on virtual ones.
+func ComputeExprDependsOnVirtualColumn(
+	desc catalog.TableDescriptor, col catalog.Column,
+) (bool, error) {
+	if !col.IsComputed() || col.IsVirtual() {
+		return false, nil
+	}
+	expr, err := parser.ParseExpr(col.GetComputeExpr())
+	if err != nil {
+		// At this point, we should be able to parse the computed expression.
+		return false, errors.WithAssertionFailure(err)
+	}
+	var dependsOnVirtual bool
+	err = iterColDescriptors(desc, expr, func(colVar catalog.Column) error {
+		dependsOnVirtual = dependsOnVirtual || colVar.IsVirtual()
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return dependsOnVirtual, nil
+}
+
+// VirtualColumnReferencedColumnIDs returns the IDs of the columns of desc
+// referenced by the compute expression of the virtual column col. Callers can
+// use it to verify that a virtual column only depends on stored columns before
+// indexing it. An error is returned if col isn't a virtual computed column, or
+// if its expression can't be parsed or references unknown columns.
+func VirtualColumnReferencedColumnIDs(
+	desc catalog.TableDescriptor, col catalog.Column,
+) (catalog.TableColSet, error) {
+	if !col.IsVirtual() || !col.IsComputed() {
+		return catalog.TableColSet{}, errors.AssertionFailedf(
+			"column %q is not a virtual computed column", col.GetName())
+	}
+	expr, err := parser.ParseExpr(col.GetComputeExpr())
+	if err != nil {
+		// At this point, we should be able to parse the computed expression.
+		return catalog.TableColSet{}, errors.WithAssertionFailure(err)
+	}
+	return ExtractColumnIDs(desc, expr)
+}
+
+// ValidateComputedColumnSwap verifies that the computed column swap mutation
+// of desc, as queued by ALTER COLUMN TYPE, can be performed. This is the case
+// if all of the following are true:
+//
+//   - The old column is public. It's dropped once the swap is performed.
+//   - The new column is a stored computed column added by the same mutation as
+//     the swap, whose expression only references existing columns other than
+//     itself.
+//   - The type of the old column can be assignment-cast to the type of the new
+//     column, as required by ALTER COLUMN TYPE.
+//   - Both columns belong to the same column family.
+//   - The inverse expression, with which the old column is computed after the
+//     swap, can be parsed.
+func ValidateComputedColumnSwap(
+	desc catalog.TableDescriptor, swap catalog.ComputedColumnSwap,
+) error {
+	swapDesc := swap.ComputedColumnSwapDesc()
+	oldCol, err := catalog.MustFindColumnByID(desc, swapDesc.OldColumnId)
+	if err != nil {
+		return err
+	}
+	newCol, err := catalog.MustFindColumnByID(desc, swapDesc.NewColumnId)
+	if err != nil {
+		return err
+	}
+	if oldCol.GetID() == newCol.GetID() {
+		return errors.AssertionFailedf(
+			"computed column swap of column %q with itself", oldCol.GetName())
+	}
+	if !oldCol.Public() {
+		return errors.AssertionFailedf(
+			"column %q to be swapped out is not public", oldCol.GetName())
+	}
+	if !newCol.Adding() || newCol.MutationID() != swap.MutationID() {
+		return errors.AssertionFailedf(
+			"column %q to be swapped in is not being added by mutation %d",
+			newCol.GetName(), swap.MutationID())
+	}
+	if !newCol.IsComputed() || newCol.IsVirtual() {
+		return errors.AssertionFailedf(
+			"column %q to be swapped in is not a stored computed column", newCol.GetName())
+	}
+	expr, err := parser.ParseExpr(newCol.GetComputeExpr())
+	if err != nil {
+		// At this point, we should be able to parse the computed expression.
+		return errors.WithAssertionFailure(err)
+	}
+	colIDs, err := ExtractColumnIDs(desc, expr)
+	if err != nil {
+		return err
+	}
+	if colIDs.Contains(newCol.GetID()) {
+		return errors.AssertionFailedf(
+			"computed column %q references itself", newCol.GetName())
+	}
+	if !cast.ValidCast(oldCol.GetType(), newCol.GetType(), cast.ContextAssignment) {
+		return errors.AssertionFailedf(
+			"type %s of column %q can't be cast to type %s of column %q to be swapped in",
+			oldCol.GetType().SQLStringForError(), oldCol.GetName(),
+			newCol.GetType().SQLStringForError(), newCol.GetName())
+	}
+	sameFamily := false
+	for _, family := range desc.GetFamilies() {
+		if descpb.ColumnIDs(family.ColumnIDs).Contains(oldCol.GetID()) {
+			sameFamily = descpb.ColumnIDs(family.ColumnIDs).Contains(newCol.GetID())
+			break
+		}
+	}
+	if !sameFamily {
+		return errors.AssertionFailedf(
+			"columns %q and %q of computed column swap are not in the same column family",
+			oldCol.GetName(), newCol.GetName())
+	}
+	if _, err := parser.ParseExpr(swapDesc.InverseExpr); err != nil {
+		return errors.NewAssertionErrorWithWrappedErrf(err,
+			"failed to parse inverse expression of computed column swap")
+	}
+	return nil
+}
+
 // MakeComputedExprs returns a slice of the computed expressions for the
 // slice of input column descriptors, or nil if none of the input column
 // descriptors have computed expressions. The caller provides the set of
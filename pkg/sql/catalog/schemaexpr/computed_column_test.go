@@ -0,0 +1,246 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package schemaexpr_test
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeExprDependsOnVirtualColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames()
+
+	desc := testTableDescWithPK([]descpb.ColumnDescriptor{
+		{ID: 1, Name: "a", Type: types.Int},
+		testComputedCol(2, "v", "a + 1:::INT8", true /* virtual */),
+		testComputedCol(3, "s", "a * 2:::INT8", false /* virtual */),
+		testComputedCol(4, "s_on_s", "s + 1:::INT8", false /* virtual */),
+		testComputedCol(5, "s_on_v", "abs(v)", false /* virtual */),
+		testComputedCol(6, "v_on_v", "v + s", true /* virtual */),
+		testComputedCol(7, "s_on_v_on_v", "a + v_on_v", false /* virtual */),
+		testComputedCol(8, "s_unknown", "b + 1:::INT8", false /* virtual */),
+	}, nil /* mutate */)
+
+	for _, tc := range []struct {
+		col      string
+		expected bool
+		err      bool
+	}{
+		// Not a stored computed column.
+		{col: "a", expected: false},
+		{col: "v", expected: false},
+		{col: "v_on_v", expected: false},
+		// Valid stored computed columns.
+		{col: "s", expected: false},
+		{col: "s_on_s", expected: false},
+		// Invalid stored computed columns.
+		{col: "s_on_v", expected: true},
+		{col: "s_on_v_on_v", expected: true},
+		// References an unknown column.
+		{col: "s_unknown", err: true},
+	} {
+		t.Run(tc.col, func(t *testing.T) {
+			col, err := catalog.MustFindColumnByName(desc, tc.col)
+			require.NoError(t, err)
+			actual, err := schemaexpr.ComputeExprDependsOnVirtualColumn(desc, col)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestVirtualColumnReferencedColumnIDs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames()
+
+	desc := testTableDescWithPK([]descpb.ColumnDescriptor{
+		{ID: 1, Name: "a", Type: types.Int},
+		{ID: 2, Name: "b", Type: types.Int, Nullable: true},
+		testComputedCol(3, "v", "a + 1:::INT8", true /* virtual */),
+		testComputedCol(4, "v_a_b", "a + abs(b)", true /* virtual */),
+		testComputedCol(5, "v_on_v", "v * 2:::INT8", true /* virtual */),
+		testComputedCol(6, "v_const", "1:::INT8", true /* virtual */),
+		testComputedCol(7, "v_unknown", "c + 1:::INT8", true /* virtual */),
+		testComputedCol(8, "v_invalid", "a +", true /* virtual */),
+		testComputedCol(9, "s", "a * 2:::INT8", false /* virtual */),
+	}, nil /* mutate */)
+
+	for _, tc := range []struct {
+		col      string
+		expected string
+		err      string
+	}{
+		{col: "v", expected: "{1}"},
+		{col: "v_a_b", expected: "{1,2}"},
+		// References to other virtual columns are returned as well.
+		{col: "v_on_v", expected: "{3}"},
+		{col: "v_const", expected: "{}"},
+		{col: "v_unknown", err: `column "c" does not exist`},
+		{col: "v_invalid", err: "syntax error"},
+		// Not virtual computed columns.
+		{col: "s", err: `column "s" is not a virtual computed column`},
+		{col: "b", err: `column "b" is not a virtual computed column`},
+	} {
+		t.Run(tc.col, func(t *testing.T) {
+			col, err := catalog.MustFindColumnByName(desc, tc.col)
+			require.NoError(t, err)
+			colIDs, err := schemaexpr.VirtualColumnReferencedColumnIDs(desc, col)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, colIDs.String())
+		})
+	}
+}
+
+func TestValidateComputedColumnSwap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames()
+
+	shadowExpr := "b::STRING"
+	makeDesc := func(mutate func(desc *descpb.TableDescriptor)) catalog.TableDescriptor {
+		desc := descpb.TableDescriptor{
+			ID:   1,
+			Name: "foo",
+			Columns: []descpb.ColumnDescriptor{
+				{ID: 1, Name: "a", Type: types.Int},
+				{ID: 2, Name: "b", Type: types.Int, Nullable: true},
+			},
+			Families: []descpb.ColumnFamilyDescriptor{
+				{ID: 0, Name: "primary", ColumnIDs: []descpb.ColumnID{1, 2, 3}, ColumnNames: []string{"a", "b", "b_shadow"}},
+			},
+			PrimaryIndex: descpb.IndexDescriptor{
+				ID: 1, Name: "foo_pkey", KeyColumnIDs: []descpb.ColumnID{1}, KeyColumnNames: []string{"a"},
+				KeyColumnDirections: []catenumpb.IndexColumn_Direction{catenumpb.IndexColumn_ASC},
+				StoreColumnIDs:      []descpb.ColumnID{2, 3},
+				StoreColumnNames:    []string{"b", "b_shadow"},
+				EncodingType:        catenumpb.PrimaryIndexEncoding,
+			},
+			Mutations: []descpb.DescriptorMutation{
+				{
+					Descriptor_: &descpb.DescriptorMutation_Column{
+						Column: &descpb.ColumnDescriptor{
+							ID: 3, Name: "b_shadow", Type: types.String, Nullable: true, ComputeExpr: &shadowExpr,
+						},
+					},
+					State:      descpb.DescriptorMutation_WRITE_ONLY,
+					Direction:  descpb.DescriptorMutation_ADD,
+					MutationID: 1,
+				},
+				{
+					Descriptor_: &descpb.DescriptorMutation_ComputedColumnSwap{
+						ComputedColumnSwap: &descpb.ComputedColumnSwap{
+							OldColumnId: 2,
+							NewColumnId: 3,
+							InverseExpr: "b::INT8",
+						},
+					},
+					State:      descpb.DescriptorMutation_WRITE_ONLY,
+					Direction:  descpb.DescriptorMutation_ADD,
+					MutationID: 1,
+				},
+			},
+		}
+		if mutate != nil {
+			mutate(&desc)
+		}
+		return tabledesc.NewBuilder(&desc).BuildImmutableTable()
+	}
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(desc *descpb.TableDescriptor)
+		err    string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "identical type",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Mutations[0].GetColumn().Type = types.Int
+				shadowExpr := "b"
+				desc.Mutations[0].GetColumn().ComputeExpr = &shadowExpr
+			},
+		},
+		{
+			name: "incompatible type",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Mutations[0].GetColumn().Type = types.Bool
+				shadowExpr := "b::BOOL"
+				desc.Mutations[0].GetColumn().ComputeExpr = &shadowExpr
+			},
+			err: `type INT8 of column "b" can't be cast to type BOOL of column "b_shadow" to be swapped in`,
+		},
+		{
+			name: "not computed",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Mutations[0].GetColumn().ComputeExpr = nil
+			},
+			err: `column "b_shadow" to be swapped in is not a stored computed column`,
+		},
+		{
+			name: "other mutation",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Mutations[0].MutationID = 2
+			},
+			err: `column "b_shadow" to be swapped in is not being added by mutation 1`,
+		},
+		{
+			name: "other family",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Families = []descpb.ColumnFamilyDescriptor{
+					{ID: 0, Name: "primary", ColumnIDs: []descpb.ColumnID{1, 2}, ColumnNames: []string{"a", "b"}},
+					{ID: 1, Name: "shadow", ColumnIDs: []descpb.ColumnID{3}, ColumnNames: []string{"b_shadow"}},
+				}
+			},
+			err: `columns "b" and "b_shadow" of computed column swap are not in the same column family`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := makeDesc(tc.mutate)
+			var swap catalog.ComputedColumnSwap
+			for _, m := range desc.AllMutations() {
+				if s := m.AsComputedColumnSwap(); s != nil {
+					swap = s
+				}
+			}
+			require.NotNil(t, swap)
+			err := schemaexpr.ValidateComputedColumnSwap(desc, swap)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -10,7 +10,10 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins/builtinsregistry"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/cast"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/transform"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -68,25 +71,8 @@ func MakeDefaultExprs(
 		if err != nil {
 			return nil, err
 		}
-		// If the DEFAULT expression has a type that is not identical to the
-		// column's type, wrap the default expression in an assignment cast.
-		if !typedExpr.ResolvedType().Identical(col.GetType()) {
-			const fnName = "crdb_internal.assignment_cast"
-			funcRef := tree.WrapFunction(fnName)
-			props, overloads := builtinsregistry.GetBuiltinProperties(fnName)
-			if typedExpr, err = tree.TypeCheck(ctx, tree.NewTypedFuncExpr(
-				funcRef,
-				0, /* aggQualifier */
-				tree.TypedExprs{typedExpr, tree.NewTypedCastExpr(tree.DNull, col.GetType())},
-				nil, /* filter */
-				nil, /* windowDef */
-				col.GetType(),
-				props,
-				&overloads[0],
-			), semaCtx, col.GetType()); err != nil {
-				return nil, errors.NewAssertionErrorWithWrappedErrf(err,
-					"failed to type check the cast of %v to %v", expr, col.GetType())
-			}
+		if typedExpr, err = maybeWrapInAssignmentCast(ctx, semaCtx, expr, typedExpr, col); err != nil {
+			return nil, err
 		}
 		if typedExpr, err = txCtx.NormalizeExpr(ctx, evalCtx, typedExpr); err != nil {
 			return nil, err
@@ -97,6 +83,69 @@ func MakeDefaultExprs(
 	return defaultExprs, nil
 }
 
+// TypeCheckedDefaultExpr returns the DEFAULT expression of col type-checked
+// against the type of the column, or nil if the column has no DEFAULT
+// expression. If the type of the expression is not identical to the column's
+// type, the expression is wrapped in an assignment cast, as is done for the
+// default expressions of INSERT. An error is returned if the expression can't
+// be assignment-cast to the column's type.
+func TypeCheckedDefaultExpr(
+	ctx context.Context, semaCtx *tree.SemaContext, col catalog.Column,
+) (tree.TypedExpr, error) {
+	if !col.HasDefault() {
+		return nil, nil
+	}
+	expr, err := parser.ParseExpr(col.GetDefaultExpr())
+	if err != nil {
+		return nil, err
+	}
+	typedExpr, err := tree.TypeCheck(ctx, expr, semaCtx, col.GetType())
+	if err != nil {
+		return nil, err
+	}
+	if typ := typedExpr.ResolvedType(); typedExpr != tree.DNull &&
+		!typ.Equivalent(col.GetType()) &&
+		!cast.ValidCast(typ, col.GetType(), cast.ContextAssignment) {
+		return nil, pgerror.Newf(pgcode.DatatypeMismatch,
+			"column %q is of type %s but default expression is of type %s",
+			col.GetName(), col.GetType().SQLStringForError(), typ.SQLStringForError())
+	}
+	return maybeWrapInAssignmentCast(ctx, semaCtx, expr, typedExpr, col)
+}
+
+// maybeWrapInAssignmentCast wraps typedExpr, the type-checked DEFAULT
+// expression expr of col, in an assignment cast if its type is not identical
+// to the column's type. Otherwise, typedExpr is returned as is.
+func maybeWrapInAssignmentCast(
+	ctx context.Context,
+	semaCtx *tree.SemaContext,
+	expr tree.Expr,
+	typedExpr tree.TypedExpr,
+	col catalog.Column,
+) (tree.TypedExpr, error) {
+	if typedExpr.ResolvedType().Identical(col.GetType()) {
+		return typedExpr, nil
+	}
+	const fnName = "crdb_internal.assignment_cast"
+	funcRef := tree.WrapFunction(fnName)
+	props, overloads := builtinsregistry.GetBuiltinProperties(fnName)
+	typedExpr, err := tree.TypeCheck(ctx, tree.NewTypedFuncExpr(
+		funcRef,
+		0, /* aggQualifier */
+		tree.TypedExprs{typedExpr, tree.NewTypedCastExpr(tree.DNull, col.GetType())},
+		nil, /* filter */
+		nil, /* windowDef */
+		col.GetType(),
+		props,
+		&overloads[0],
+	), semaCtx, col.GetType())
+	if err != nil {
+		return nil, errors.NewAssertionErrorWithWrappedErrf(err,
+			"failed to type check the cast of %v to %v", expr, col.GetType())
+	}
+	return typedExpr, nil
+}
+
 // ProcessColumnSet returns columns in cols, and other writable
 // columns in tableDesc that fulfills a given criteria in inSet.
 func ProcessColumnSet(
@@ -0,0 +1,77 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package schemaexpr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeCheckedDefaultExpr(t *testing.T) {
+	ctx := context.Background()
+	semaCtx := tree.MakeSemaContext(nil /* resolver */)
+
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames()
+
+	strPtr := func(s string) *string { return &s }
+	desc := tabledesc.NewBuilder(&descpb.TableDescriptor{
+		ID:   1,
+		Name: "t",
+		Columns: []descpb.ColumnDescriptor{
+			{ID: 1, Name: "none", Type: types.Int},
+			{ID: 2, Name: "same", Type: types.Int, DefaultExpr: strPtr("42:::INT8")},
+			{ID: 3, Name: "narrower", Type: types.Int2, DefaultExpr: strPtr("42:::INT8")},
+			{ID: 4, Name: "null", Type: types.Int, DefaultExpr: strPtr("NULL")},
+			{ID: 5, Name: "mismatch", Type: types.Int, DefaultExpr: strPtr("true")},
+		},
+	}).BuildImmutableTable()
+
+	for _, tc := range []struct {
+		col          string
+		expectedType *types.T
+		// wrapped is true if the expression is expected to be wrapped in an
+		// assignment cast.
+		wrapped bool
+		err     string
+	}{
+		{col: "none"},
+		{col: "same", expectedType: types.Int},
+		{col: "narrower", expectedType: types.Int2, wrapped: true},
+		{col: "null", expectedType: types.Int, wrapped: true},
+		{
+			col: "mismatch",
+			err: `column "mismatch" is of type INT8 but default expression is of type BOOL`,
+		},
+	} {
+		t.Run(tc.col, func(t *testing.T) {
+			col, err := catalog.MustFindColumnByName(desc, tc.col)
+			require.NoError(t, err)
+			typedExpr, err := schemaexpr.TypeCheckedDefaultExpr(ctx, &semaCtx, col)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectedType == nil {
+				require.Nil(t, typedExpr)
+				return
+			}
+			require.True(t, tc.expectedType.Identical(typedExpr.ResolvedType()))
+			_, isFuncExpr := typedExpr.(*tree.FuncExpr)
+			require.Equal(t, tc.wrapped, isFuncExpr)
+		})
+	}
+}
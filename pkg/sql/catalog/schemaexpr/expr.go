@@ -160,6 +160,79 @@ func ExtractColumnIDs(
 	return colIDs, err
 }
 
+// ColumnsUsedInOnUpdate returns the set of IDs of the columns referenced in the
+// ON UPDATE expressions of the non-dropped columns of desc.
+func ColumnsUsedInOnUpdate(desc catalog.TableDescriptor) (catalog.TableColSet, error) {
+	var colIDs catalog.TableColSet
+	for _, col := range desc.NonDropColumns() {
+		if !col.HasOnUpdate() {
+			continue
+		}
+		expr, err := parser.ParseExpr(col.GetOnUpdateExpr())
+		if err != nil {
+			return catalog.TableColSet{}, errors.Wrapf(err,
+				"failed to parse ON UPDATE expression of column %q", col.GetName())
+		}
+		referenced, err := ExtractColumnIDs(desc, expr)
+		if err != nil {
+			return catalog.TableColSet{}, err
+		}
+		colIDs.UnionWith(referenced)
+	}
+	return colIDs, nil
+}
+
+// AllExpressionReferencedColumnIDs returns the set of IDs of the columns
+// referenced by any expression of desc: the DEFAULT, ON UPDATE and computed
+// expressions of its non-dropped columns, its check constraints, including
+// those being added or dropped, and the predicates of its non-dropped partial
+// indexes.
+func AllExpressionReferencedColumnIDs(desc catalog.TableDescriptor) (catalog.TableColSet, error) {
+	var colIDs catalog.TableColSet
+	addReferenced := func(exprStr, kind, name string) error {
+		expr, err := parser.ParseExpr(exprStr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s expression of %q", kind, name)
+		}
+		referenced, err := ExtractColumnIDs(desc, expr)
+		if err != nil {
+			return err
+		}
+		colIDs.UnionWith(referenced)
+		return nil
+	}
+	for _, col := range desc.NonDropColumns() {
+		if col.HasDefault() {
+			if err := addReferenced(col.GetDefaultExpr(), "DEFAULT", col.GetName()); err != nil {
+				return catalog.TableColSet{}, err
+			}
+		}
+		if col.HasOnUpdate() {
+			if err := addReferenced(col.GetOnUpdateExpr(), "ON UPDATE", col.GetName()); err != nil {
+				return catalog.TableColSet{}, err
+			}
+		}
+		if col.IsComputed() {
+			if err := addReferenced(col.GetComputeExpr(), "computed", col.GetName()); err != nil {
+				return catalog.TableColSet{}, err
+			}
+		}
+	}
+	for _, ck := range desc.CheckConstraints() {
+		if err := addReferenced(ck.GetExpr(), "check", ck.GetName()); err != nil {
+			return catalog.TableColSet{}, err
+		}
+	}
+	for _, idx := range desc.NonDropIndexes() {
+		if idx.IsPartial() {
+			if err := addReferenced(idx.GetPredicate(), "predicate", idx.GetName()); err != nil {
+				return catalog.TableColSet{}, err
+			}
+		}
+	}
+	return colIDs, nil
+}
+
 type returnFalse struct{}
 
 func (returnFalse) Error() string { panic("unimplemented") }
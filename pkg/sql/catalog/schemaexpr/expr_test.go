@@ -10,6 +10,9 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
@@ -17,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/volatility"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidateExpr(t *testing.T) {
@@ -122,13 +126,13 @@ func TestExtractColumnIDs(t *testing.T) {
 		expr     string
 		expected string
 	}{
-		{"true", "()"},
-		{"now()", "()"},
-		{"a", "(1)"},
-		{"a AND b > 1", "(1,2)"},
-		{"a AND c = 'foo'", "(1,3)"},
-		{"a OR (b > 1 AND c = 'foo')", "(1-3)"},
-		{"a AND abs(b) > 5 AND lower(c) = 'foo'", "(1-3)"},
+		{"true", "{}"},
+		{"now()", "{}"},
+		{"a", "{1}"},
+		{"a AND b > 1", "{1,2}"},
+		{"a AND c = 'foo'", "{1,3}"},
+		{"a OR (b > 1 AND c = 'foo')", "{1,2,3}"},
+		{"a AND abs(b) > 5 AND lower(c) = 'foo'", "{1,2,3}"},
 	}
 
 	for _, d := range testData {
@@ -150,6 +154,136 @@ func TestExtractColumnIDs(t *testing.T) {
 	}
 }
 
+func TestColumnsUsedInOnUpdate(t *testing.T) {
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames()
+
+	desc := testTableDesc(
+		"foo",
+		[]testCol{{"a", types.Int}, {"b", types.Int}, {"c", types.Int}, {"d", types.Int}, {"e", types.Int}},
+		nil, /* mutationColumns */
+	)
+	colIDs, err := schemaexpr.ColumnsUsedInOnUpdate(desc)
+	require.NoError(t, err)
+	require.True(t, colIDs.Empty())
+
+	setOnUpdate := func(colName, expr string) {
+		col, err := catalog.MustFindColumnByName(desc, colName)
+		require.NoError(t, err)
+		col.ColumnDesc().OnUpdateExpr = &expr
+	}
+	setOnUpdate("c", "a + 1:::INT8")
+	setOnUpdate("d", "a * b")
+	setOnUpdate("e", "unique_rowid()")
+	colIDs, err = schemaexpr.ColumnsUsedInOnUpdate(desc)
+	require.NoError(t, err)
+	require.Equal(t, "{1,2}", colIDs.String())
+
+	setOnUpdate("e", "z + 1:::INT8")
+	_, err = schemaexpr.ColumnsUsedInOnUpdate(desc)
+	require.Error(t, err)
+}
+
+func TestAllExpressionReferencedColumnIDs(t *testing.T) {
+	// Trick to get the init() for the builtins package to run.
+	_ = builtins.AllBuiltinNames()
+
+	strPtr := func(s string) *string { return &s }
+	makeDesc := func(mutate func(desc *descpb.TableDescriptor)) catalog.TableDescriptor {
+		return testTableDescWithPK([]descpb.ColumnDescriptor{
+			{ID: 1, Name: "a", Type: types.Int},
+			{ID: 2, Name: "b", Type: types.Int},
+			{ID: 3, Name: "c", Type: types.Int},
+			{ID: 4, Name: "d", Type: types.Int},
+		}, mutate)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		mutate   func(desc *descpb.TableDescriptor)
+		expected string
+		err      bool
+	}{
+		{
+			name:     "none",
+			expected: "{}",
+		},
+		// DEFAULT expressions can't reference columns, but are parsed anyway.
+		{
+			name: "default",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Columns[3].DefaultExpr = strPtr("unique_rowid()")
+				desc.Columns[2].DefaultExpr = strPtr("1:::INT8")
+			},
+			expected: "{}",
+		},
+		{
+			name: "on update",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Columns[3].OnUpdateExpr = strPtr("a + 1:::INT8")
+			},
+			expected: "{1}",
+		},
+		{
+			name: "computed",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Columns[3].ComputeExpr = strPtr("a + b")
+			},
+			expected: "{1,2}",
+		},
+		{
+			name: "check",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Checks = []*descpb.TableDescriptor_CheckConstraint{
+					{Name: "check_c", Expr: "c > 0:::INT8", ColumnIDs: []descpb.ColumnID{3}, ConstraintID: 2},
+				}
+			},
+			expected: "{3}",
+		},
+		{
+			name: "partial index",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Indexes = []descpb.IndexDescriptor{
+					{ID: 2, Name: "partial", KeyColumnIDs: []descpb.ColumnID{2},
+						KeyColumnNames:      []string{"b"},
+						KeyColumnDirections: []catenumpb.IndexColumn_Direction{catenumpb.IndexColumn_ASC},
+						KeySuffixColumnIDs:  []descpb.ColumnID{1},
+						Predicate:           "d IS NOT NULL",
+					},
+				}
+			},
+			expected: "{4}",
+		},
+		{
+			name: "all",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Columns[3].ComputeExpr = strPtr("b * 2:::INT8")
+				desc.Checks = []*descpb.TableDescriptor_CheckConstraint{
+					{Name: "check_c", Expr: "c > 0:::INT8", ColumnIDs: []descpb.ColumnID{3}, ConstraintID: 2},
+				}
+			},
+			expected: "{2,3}",
+		},
+		{
+			name: "unknown column",
+			mutate: func(desc *descpb.TableDescriptor) {
+				desc.Columns[3].ComputeExpr = strPtr("z + 1:::INT8")
+			},
+			err: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			colIDs, err := schemaexpr.AllExpressionReferencedColumnIDs(makeDesc(tc.mutate))
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, colIDs.String())
+		})
+	}
+}
+
 func TestValidColumnReferences(t *testing.T) {
 	// Trick to get the init() for the builtins package to run.
 	_ = builtins.AllBuiltinNames()
@@ -7,6 +7,7 @@ package schemaexpr_test
 
 import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -54,3 +55,44 @@ func testTableDesc(
 		Mutations: muts,
 	}).BuildCreatedMutableTable()
 }
+
+// testComputedCol returns the descriptor of a nullable INT column computed by
+// expr.
+func testComputedCol(
+	id descpb.ColumnID, name, expr string, virtual bool,
+) descpb.ColumnDescriptor {
+	return descpb.ColumnDescriptor{
+		ID: id, Name: name, Type: types.Int, Nullable: true, ComputeExpr: &expr, Virtual: virtual,
+	}
+}
+
+// testTableDescWithPK builds a table foo with the given columns and a primary
+// index foo_pkey on the first of them, which stores every other non-virtual
+// column. If mutate is non-nil, it's applied to the descriptor before it's
+// built.
+func testTableDescWithPK(
+	columns []descpb.ColumnDescriptor, mutate func(desc *descpb.TableDescriptor),
+) catalog.TableDescriptor {
+	desc := descpb.TableDescriptor{
+		ID:      1,
+		Name:    "foo",
+		Columns: columns,
+		PrimaryIndex: descpb.IndexDescriptor{
+			ID: 1, Name: "foo_pkey",
+			KeyColumnIDs:        []descpb.ColumnID{columns[0].ID},
+			KeyColumnNames:      []string{columns[0].Name},
+			KeyColumnDirections: []catenumpb.IndexColumn_Direction{catenumpb.IndexColumn_ASC},
+			EncodingType:        catenumpb.PrimaryIndexEncoding,
+		},
+	}
+	for _, col := range columns[1:] {
+		if !col.Virtual {
+			desc.PrimaryIndex.StoreColumnIDs = append(desc.PrimaryIndex.StoreColumnIDs, col.ID)
+			desc.PrimaryIndex.StoreColumnNames = append(desc.PrimaryIndex.StoreColumnNames, col.Name)
+		}
+	}
+	if mutate != nil {
+		mutate(&desc)
+	}
+	return tabledesc.NewBuilder(&desc).BuildImmutableTable()
+}
@@ -6,6 +6,9 @@
 package catalog
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/util/intsets"
 )
@@ -90,10 +93,21 @@ func (s TableColSet) Ordered() []descpb.ColumnID {
 // UnionWith adds all the columns from rhs to this set.
 func (s *TableColSet) UnionWith(rhs TableColSet) { s.set.UnionWith(rhs.set) }
 
-// String returns a list representation of elements. Sequential runs of positive
-// numbers are shown as ranges. For example, for the set {1, 2, 3  5, 6, 10},
-// the output is "(1-3,5,6,10)".
-func (s TableColSet) String() string { return s.set.String() }
+// String returns a list representation of the elements in increasing order,
+// enclosed in braces. For example, for the set {1, 2, 3, 5, 6, 10}, the output
+// is "{1,2,3,5,6,10}".
+func (s TableColSet) String() string {
+	var buf strings.Builder
+	buf.WriteByte('{')
+	s.ForEach(func(col descpb.ColumnID) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.Itoa(int(col)))
+	})
+	buf.WriteByte('}')
+	return buf.String()
+}
 
 // Intersects returns true if s has any elements in common with rhs.
 func (s TableColSet) Intersects(rhs TableColSet) bool { return s.set.Intersects(rhs.set) }
@@ -60,3 +60,22 @@ func TestColSet_Ordered(t *testing.T) {
 		})
 	}
 }
+
+func TestColSet_String(t *testing.T) {
+	testData := []struct {
+		set      TableColSet
+		expected string
+	}{
+		{TableColSet{}, "{}"},
+		{MakeTableColSet(1), "{1}"},
+		{MakeTableColSet(1, 2, 5), "{1,2,5}"},
+		{MakeTableColSet(10, 3, 1, 2), "{1,2,3,10}"},
+		{MakeTableColSet(1, 2, 3, 5, 6, 100), "{1,2,3,5,6,100}"},
+	}
+
+	for _, d := range testData {
+		if actual := d.set.String(); actual != d.expected {
+			t.Errorf("expected %q, got %q", d.expected, actual)
+		}
+	}
+}
@@ -6,10 +6,18 @@
 package catalog
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
@@ -96,6 +104,14 @@ type Mutation interface {
 	// nil otherwise.
 	AsIndex() Index
 
+	// Column returns the corresponding Column and true if the mutation is on a
+	// column, nil and false otherwise.
+	Column() (Column, bool)
+
+	// Index returns the corresponding Index and true if the mutation is on an
+	// index, nil and false otherwise.
+	Index() (Index, bool)
+
 	// AsConstraintWithoutIndex returns the corresponding WithoutIndexConstraint
 	// if the mutation is on a check constraint or on a foreign key constraint or
 	// on a non-index-backed unique constraint, nil otherwise.
@@ -179,27 +195,145 @@ type Index interface {
 	GetSharded() catpb.ShardedDescriptor
 	GetShardColumnName() string
 
+	// ShardColumn returns the shard column of the index in desc, along with
+	// true, if the index is hash sharded. Returns false otherwise, or if the
+	// shard column can't be found.
+	ShardColumn(desc TableDescriptor) (Column, bool)
+
+	// ShardColumnIDs returns the IDs of the columns which the shard column of
+	// the index is computed over, in the order in which they're passed to the
+	// shard function, or nil if the index isn't hash sharded. An error is
+	// returned if any of these columns isn't a key column of the index.
+	ShardColumnIDs() (descpb.ColumnIDs, error)
+
 	// IsValidOriginIndex returns whether the index can serve as an origin index
-	// for a foreign key constraint.
+	// for a foreign key constraint, i.e. whether it's not partial and its key
+	// columns start with the origin columns of the foreign key. The key columns
+	// need not match the origin columns exactly.
 	IsValidOriginIndex(fk ForeignKeyConstraint) bool
 
+	// CanBeOriginForColumns returns whether the index can be used to enforce an
+	// outbound foreign key on the given origin columns, i.e. whether it's a
+	// valid origin index for these columns which is either public or writable.
+	CanBeOriginForColumns(colIDs descpb.ColumnIDs) bool
+
+	// PredicateReferencesColumn returns true iff the index is partial and its
+	// predicate references the column with the given ID in desc.
+	PredicateReferencesColumn(desc TableDescriptor, colID descpb.ColumnID) (bool, error)
+
+	// PredicateColumnIDs returns the IDs of the columns in desc referenced by
+	// the predicate of the index, in increasing order, or nil if the index isn't
+	// partial.
+	PredicateColumnIDs(desc TableDescriptor) (descpb.ColumnIDs, error)
+
+	// PredicateIsImmutable returns true iff the predicate of the index, which
+	// references columns of desc, is immutable, i.e. contains neither stable
+	// nor volatile operators. Returns true if the index isn't partial, and an
+	// error if the predicate can't be parsed or type-checked.
+	PredicateIsImmutable(
+		ctx context.Context, semaCtx *tree.SemaContext, desc TableDescriptor,
+	) (bool, error)
+
+	// ExpressionColumnExprs returns the expressions of the expression index
+	// elements of the index, in key order, i.e. the compute expressions of the
+	// inaccessible virtual columns of desc backing its key columns. Returns nil
+	// if the index doesn't contain any expressions.
+	ExpressionColumnExprs(desc TableDescriptor) ([]string, error)
+
 	GetPartitioning() Partitioning
 	PartitioningColumnCount() int
 	ImplicitPartitioningColumnCount() int
 
 	ExplicitColumnStartIdx() int
 
+	// IsPartitioningColumn returns true iff the column with the given ID is one
+	// of the leading key columns of the index by which it is partitioned, be it
+	// implicitly or explicitly. Subpartitioning columns aren't considered.
+	IsPartitioningColumn(id descpb.ColumnID) bool
+
+	// PartitionRegionNames returns the names of the partitions of the index if
+	// it's implicitly partitioned by the region column of desc, as is the case
+	// for the indexes of REGIONAL BY ROW tables, in which case each partition is
+	// named after the region it holds. Returns an error otherwise.
+	PartitionRegionNames(desc TableDescriptor) ([]string, error)
+
+	// AllPartitionNames returns the names of all the partitions of the index,
+	// including subpartitions, each partition being followed by its
+	// subpartitions. Returns nil if the index isn't partitioned.
+	AllPartitionNames() []string
+
+	// IsRegionalByRowPartitioned returns true iff desc is a REGIONAL BY ROW
+	// table and the index is implicitly partitioned by its region column, i.e.
+	// the region column is the leading implicit partitioning column of the
+	// index.
+	IsRegionalByRowPartitioned(desc TableDescriptor) bool
+
 	NumKeyColumns() int
 	GetKeyColumnID(columnOrdinal int) descpb.ColumnID
 	GetKeyColumnName(columnOrdinal int) string
 	GetKeyColumnDirection(columnOrdinal int) catenumpb.IndexColumn_Direction
 
+	// KeyColumnPosition returns the ordinal of the column with the given ID
+	// among the key columns of the index, or -1 if it isn't a key column.
+	KeyColumnPosition(id descpb.ColumnID) int
+
+	// DirectionForKeyColumn returns the direction of the column with the given
+	// ID in the index key, along with true, if it's a key column of the index.
+	// Returns false otherwise.
+	DirectionForKeyColumn(id descpb.ColumnID) (catenumpb.IndexColumn_Direction, bool)
+
+	// KeyColumnIDToPositionMap returns a map from the IDs of the key columns of
+	// the index to their ordinal in the index key.
+	KeyColumnIDToPositionMap() TableColMap
+
 	CollectKeyColumnIDs() TableColSet
 	CollectKeySuffixColumnIDs() TableColSet
 	CollectPrimaryStoredColumnIDs() TableColSet
 	CollectSecondaryStoredColumnIDs() TableColSet
 	CollectCompositeColumnIDs() TableColSet
 
+	// HasStoredColumn returns true iff the column with the given ID is a stored
+	// column of the index, be it a primary or a secondary index. Key and key
+	// suffix columns aren't stored columns.
+	HasStoredColumn(id descpb.ColumnID) bool
+
+	// RedundantStoredColumnIDs returns the set of stored columns of a secondary
+	// index which are also key or key suffix columns of the index, and which
+	// therefore needn't be stored. Validation rules these out for indexes at
+	// StrictIndexColumnIDGuaranteesVersion or later, but older indexes may
+	// still have some.
+	RedundantStoredColumnIDs() TableColSet
+
+	// NumColumns returns the number of distinct columns physically involved in
+	// the index, i.e. the key, key suffix, stored and composite columns. For the
+	// primary index this is every column stored in the table.
+	NumColumns() int
+
+	// EstimatedRowWidth returns an estimate of the number of bytes in a row of
+	// the index, based on the types of the columns in desc involved in it (see
+	// NumColumns). Variable-width columns are estimated conservatively.
+	EstimatedRowWidth(desc TableDescriptor) int
+
+	// CoversAllColumns returns true iff every public non-virtual column of desc
+	// is a key, key suffix or stored column of the index, in which case reading
+	// from the index never requires an index join. This always holds for the
+	// primary index.
+	CoversAllColumns(desc TableDescriptor) bool
+
+	// ReadableColumnIDs returns the set of columns of desc whose values can be
+	// decoded from the index alone, without an index join: its key, key suffix,
+	// stored and composite columns. For the primary index, this is every
+	// non-virtual column of desc, including those being added or dropped.
+	ReadableColumnIDs(desc TableDescriptor) TableColSet
+
+	// SpanForKeyPrefix returns the span of the index in desc containing all keys
+	// whose leading key columns have the values in prefix, which may not have
+	// more values than there are key columns. The values must have the types of
+	// the corresponding columns in desc, or be NULL.
+	SpanForKeyPrefix(
+		codec keys.SQLCodec, desc TableDescriptor, prefix tree.Datums,
+	) (roachpb.Span, error)
+
 	// InvertedColumnID returns the ColumnID of the inverted column of the
 	// inverted index.
 	//
@@ -218,6 +352,10 @@ type Index interface {
 	// Panics if the index is not inverted.
 	InvertedColumnKeyType() *types.T
 
+	// InvertedColumnKeyTypeSafe is like InvertedColumnKeyType, but returns
+	// false instead of panicking if the index is not inverted.
+	InvertedColumnKeyTypeSafe() (*types.T, bool)
+
 	// InvertedColumnKind returns the kind of the inverted column of the inverted
 	// index.
 	InvertedColumnKind() catpb.InvertedIndexColumnKind
@@ -228,9 +366,25 @@ type Index interface {
 	GetStoredColumnName(storedColumnOrdinal int) string
 	HasOldStoredColumns() bool
 
+	// StoredColumnOrdinals returns, for each stored column of the index, the
+	// ordinal of that column in desc.AllColumns(). Stored columns which can't
+	// be found in desc are mapped to -1.
+	StoredColumnOrdinals(desc TableDescriptor) []int
+
 	NumKeySuffixColumns() int
 	GetKeySuffixColumnID(extraColumnOrdinal int) descpb.ColumnID
 
+	// KeySuffixColumnNames returns the names of the key suffix columns of the
+	// index in desc, in order. Columns which can't be found in desc are named
+	// after their ID, in square brackets.
+	KeySuffixColumnNames(desc TableDescriptor) []string
+
+	// UniquenessSuffixColumnIDs returns the key suffix columns which are
+	// implicitly appended to the key of a non-unique secondary index to make it
+	// unique. These are the primary key columns which are not already part of
+	// the index key. Returns an empty slice for primary and unique indexes.
+	UniquenessSuffixColumnIDs() descpb.ColumnIDs
+
 	NumCompositeColumns() int
 	GetCompositeColumnID(compositeColumnOrdinal int) descpb.ColumnID
 	UseDeletePreservingEncoding() bool
@@ -309,15 +463,43 @@ type Column interface {
 	// ColName returns the column name as a tree.Name.
 	ColName() tree.Name
 
+	// StableIdentity returns a synthetic name for the column derived from its
+	// ID, of the form col_<id>. Unlike the column name, it is unaffected by
+	// renames, which makes it usable as a physical reference to the column in
+	// rewrites while user-facing names are in flux.
+	StableIdentity() string
+
 	// HasType returns true iff the column type is set.
 	HasType() bool
 
 	// GetType returns the column type.
 	GetType() *types.T
 
+	// TypeFamilyName returns the name of the family of the column type, or the
+	// empty string if the column type is not set.
+	TypeFamilyName() string
+
+	// ArrayElementType returns the type of the elements of the column, along
+	// with true, if the column type is an array type. Returns false otherwise.
+	ArrayElementType() (*types.T, bool)
+
+	// CollationName returns the locale of the collation of the column, along
+	// with true, if the column is a collated string. Returns false otherwise.
+	CollationName() (string, bool)
+
 	// IsNullable returns true iff the column allows NULL values.
 	IsNullable() bool
 
+	// IsEffectivelyNotNull returns true iff the column can't hold NULL values in
+	// desc, either because it's explicitly NOT NULL or because it's a key column
+	// of the primary index, which implies NOT NULL regardless of the flag.
+	IsEffectivelyNotNull(desc TableDescriptor) bool
+
+	// IsFamilyPrimaryColumn returns true iff the column is the first column of
+	// its column family in desc. Returns false if the column doesn't belong to
+	// any family, as is the case for virtual columns.
+	IsFamilyPrimaryColumn(desc TableDescriptor) bool
+
 	// HasDefault returns true iff the column has a default expression set.
 	HasDefault() bool
 
@@ -329,6 +511,17 @@ type Column interface {
 	// empty string otherwise.
 	GetDefaultExpr() string
 
+	// DefaultExprReferencesSequence returns true iff the column uses the
+	// sequence with the given ID and its default expression references it.
+	DefaultExprReferencesSequence(seqID descpb.ID) bool
+
+	// DefaultExprIsConstant returns true iff the default expression of the
+	// column, or NULL if it has none, contains no variables and only immutable
+	// operators, such that it can be evaluated once for all rows. The
+	// expression is type-checked using semaCtx, which must be able to resolve
+	// any user-defined types it references.
+	DefaultExprIsConstant(ctx context.Context, semaCtx *tree.SemaContext) (bool, error)
+
 	// HasOnUpdate returns true iff the column has an on update expression set.
 	HasOnUpdate() bool
 
@@ -336,6 +529,15 @@ type Column interface {
 	// empty string otherwise.
 	GetOnUpdateExpr() string
 
+	// OnUpdateExprTree returns the parsed on update expression of the column,
+	// or nil if it doesn't have one. The result is cached, and must therefore
+	// not be modified by the caller.
+	OnUpdateExprTree() (tree.Expr, error)
+
+	// OnUpdateIsCurrentTimestamp returns true iff the column has an on update
+	// expression which is a bare current_timestamp() call.
+	OnUpdateIsCurrentTimestamp() bool
+
 	// IsComputed returns true iff the column is a computed column.
 	IsComputed() bool
 
@@ -354,6 +556,10 @@ type Column interface {
 	// index.
 	IsExpressionIndexColumn() bool
 
+	// IsHashShardColumn returns true iff the column is the shard column of a
+	// non-dropped hash-sharded index of desc, which is the column's table.
+	IsHashShardColumn(desc TableDescriptor) bool
+
 	// NumUsesSequences returns the number of sequences used by this column.
 	NumUsesSequences() int
 
@@ -404,6 +610,11 @@ type Column interface {
 	// with GENERATED BY DEFAULT AS IDENTITY syntax.
 	IsGeneratedByDefaultAsIdentity() bool
 
+	// IsOverridableIdentity returns true iff the column is an identity column
+	// whose generated values may be overridden by the user, which is only the
+	// case for GENERATED BY DEFAULT AS IDENTITY columns.
+	IsOverridableIdentity() bool
+
 	// GetGeneratedAsIdentityType returns the type of how the column was
 	// created as an IDENTITY column.
 	// If the column is created with `GENERATED ALWAYS AS IDENTITY` syntax,
@@ -413,6 +624,11 @@ type Column interface {
 	// otherwise, returns descpb.GeneratedAsIdentityType_NOT_IDENTITY_COLUMN.
 	GetGeneratedAsIdentityType() catpb.GeneratedAsIdentityType
 
+	// GeneratedIdentitySequenceID returns the ID of the sequence backing the
+	// column's `GENERATED AS IDENTITY` values. Returns ok=false if the column is
+	// not an identity column or if it doesn't own any sequence.
+	GeneratedIdentitySequenceID() (id descpb.ID, ok bool)
+
 	// HasGeneratedAsIdentitySequenceOption returns true if there is a
 	// customized sequence option when this column is created as a
 	// `GENERATED AS IDENTITY` column.
@@ -429,6 +645,12 @@ type Column interface {
 	// and the error.
 	// Note it doesn't return the sequence owner info.
 	GetGeneratedAsIdentitySequenceOption(defaultIntSize int32) (*descpb.TableDescriptor_SequenceOpts, error)
+
+	// IdentitySequenceParams returns the start and increment values of the
+	// sequence backing the column, taking into account any customized sequence
+	// option, along with true, if the column is a `GENERATED AS IDENTITY`
+	// column. Returns false otherwise.
+	IdentitySequenceParams() (start, increment int64, ok bool, err error)
 }
 
 // Constraint is an interface around a constraint.
@@ -711,6 +933,10 @@ type Partitioning interface {
 	// NumRanges returns the number of range elements in the underlying
 	// partitioning descriptor.
 	NumRanges() int
+
+	// NumLeafPartitions returns the number of partitions which aren't
+	// subpartitioned any further, recursively across all levels of nesting.
+	NumLeafPartitions() int
 }
 
 func isIndexInSearchSet(desc TableDescriptor, opts IndexOpts, idx Index) bool {
@@ -795,6 +1021,17 @@ func ForEachDeleteOnlyNonPrimaryIndex(desc TableDescriptor, f func(idx Index) er
 	return forEachIndex(desc.DeleteOnlyNonPrimaryIndexes(), f)
 }
 
+// ForEachInvertedIndex is like ForEachIndex over the inverted indexes in
+// NonDropIndexes().
+func ForEachInvertedIndex(desc TableDescriptor, f func(idx Index) error) error {
+	return ForEachNonDropIndex(desc, func(idx Index) error {
+		if idx.GetType() != descpb.IndexDescriptor_INVERTED {
+			return nil
+		}
+		return f(idx)
+	})
+}
+
 // FindIndex returns the first index for which test returns true, nil otherwise,
 // according to the parameters in opts just like ForEachIndex.
 // Indexes are visited in their canonical order, see Index.Ordinal().
@@ -855,6 +1092,14 @@ func FindDeletableNonPrimaryIndex(desc TableDescriptor, test func(idx Index) boo
 	return findIndex(desc.DeletableNonPrimaryIndexes(), test)
 }
 
+// FindInvertedIndexOnColumn returns the first inverted index in
+// NonDropIndexes() whose inverted column is the column with the given ID.
+func FindInvertedIndexOnColumn(desc TableDescriptor, colID descpb.ColumnID) Index {
+	return FindNonDropIndex(desc, func(idx Index) bool {
+		return idx.GetType() == descpb.IndexDescriptor_INVERTED && idx.InvertedColumnID() == colID
+	})
+}
+
 // FindNonPrimaryIndex returns the first index in
 // NonPrimaryIndex() for which test returns true.
 func FindNonPrimaryIndex(desc TableDescriptor, test func(idx Index) bool) Index {
@@ -965,6 +1210,23 @@ func ColumnIDToOrdinalMap(columns []Column) TableColMap {
 	return m
 }
 
+// PrimaryKeyColumnOrdinals returns the ordinals in desc.AllColumns() of the
+// key columns of the primary index of the table, in key order. Key columns
+// which can't be found in desc are mapped to -1.
+func PrimaryKeyColumnOrdinals(desc TableDescriptor) []int {
+	pk := desc.GetPrimaryIndex()
+	colOrdinals := ColumnIDToOrdinalMap(desc.AllColumns())
+	ret := make([]int, pk.NumKeyColumns())
+	for i := range ret {
+		if ord, ok := colOrdinals.Get(pk.GetKeyColumnID(i)); ok {
+			ret[i] = ord
+		} else {
+			ret[i] = -1
+		}
+	}
+	return ret
+}
+
 // ColumnTypesWithInvertedCol returns the types of all given columns,
 // If invertedCol is non-nil, substitutes the type of the inverted
 // column instead of the column with the same ID.
@@ -1204,6 +1466,25 @@ func MustFindFamilyByID(
 	return nil, fmt.Errorf("family-id \"%d\" does not exist", id)
 }
 
+// FamilyForColumn returns the ID of the column family which the column with
+// the given ID belongs to and true, or false if there is no such family,
+// which is always the case for virtual columns since they're not stored.
+func FamilyForColumn(tbl TableDescriptor, colID descpb.ColumnID) (ret descpb.FamilyID, ok bool) {
+	if col := FindColumnByID(tbl, colID); col != nil && col.IsVirtual() {
+		return 0, false
+	}
+	_ = tbl.ForeachFamily(func(family *descpb.ColumnFamilyDescriptor) error {
+		for _, id := range family.ColumnIDs {
+			if id == colID {
+				ret, ok = family.ID, true
+				return iterutil.StopIteration()
+			}
+		}
+		return nil
+	})
+	return ret, ok
+}
+
 // FindColumnByID traverses the slice returned by the AllColumns
 // method on the table descriptor and returns the first Column that
 // matches the desired ID, or nil if none was found.
@@ -1355,3 +1636,897 @@ func FindFKReferencedUniqueConstraint(
 		referencedTable.GetName(),
 	)
 }
+
+// ValidatePrimaryIndexNoKeySuffix returns an error if the primary index of the
+// table has key suffix columns. Primary indexes are keyed exclusively on their
+// key columns, so a non-empty key suffix set is a sign of a corrupt descriptor.
+func ValidatePrimaryIndexNoKeySuffix(desc TableDescriptor) error {
+	pk := desc.GetPrimaryIndex()
+	if pk.NumKeySuffixColumns() > 0 {
+		return errors.AssertionFailedf(
+			"primary index %q unexpectedly contains %d key suffix columns, for instance column ID %d",
+			pk.GetName(), pk.NumKeySuffixColumns(), pk.GetKeySuffixColumnID(0))
+	}
+	return nil
+}
+
+// IndexKeyPrefixOf returns true iff the key columns of prefix, along with their
+// directions, form a prefix of the key columns of idx.
+func IndexKeyPrefixOf(prefix Index, idx Index) bool {
+	return CommonKeyPrefixLength(prefix, idx) == prefix.NumKeyColumns()
+}
+
+// CommonKeyPrefixLength returns the number of leading key columns which a and
+// b have in common, along with their directions.
+func CommonKeyPrefixLength(a, b Index) int {
+	n := a.NumKeyColumns()
+	if b.NumKeyColumns() < n {
+		n = b.NumKeyColumns()
+	}
+	for i := 0; i < n; i++ {
+		if a.GetKeyColumnID(i) != b.GetKeyColumnID(i) ||
+			a.GetKeyColumnDirection(i) != b.GetKeyColumnDirection(i) {
+			return i
+		}
+	}
+	return n
+}
+
+// RedundantPrefixIndexes returns a map from the ID of each public secondary
+// index which is made redundant by another active index to the ID of that
+// other index. A non-unique index is redundant if its key columns are a strict
+// prefix of the key columns of another index of the same type. Unique, partial
+// and inverted indexes are never considered redundant, nor are partial or
+// inverted indexes considered as superseding other indexes.
+func RedundantPrefixIndexes(desc TableDescriptor) map[descpb.IndexID]descpb.IndexID {
+	ret := make(map[descpb.IndexID]descpb.IndexID)
+	isCandidate := func(idx Index) bool {
+		return !idx.IsPartial() && idx.GetType() == descpb.IndexDescriptor_FORWARD
+	}
+	for _, idx := range desc.PublicNonPrimaryIndexes() {
+		if idx.IsUnique() || !isCandidate(idx) {
+			continue
+		}
+		for _, other := range desc.ActiveIndexes() {
+			if other.GetID() == idx.GetID() || !isCandidate(other) {
+				continue
+			}
+			if other.NumKeyColumns() > idx.NumKeyColumns() && IndexKeyPrefixOf(idx, other) {
+				ret[idx.GetID()] = other.GetID()
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// IndexFamilyUsage returns, for each index in the table, the IDs of the column
+// families for which the index writes a KV entry, as per IndexFamilyIDs.
+func IndexFamilyUsage(desc TableDescriptor) map[descpb.IndexID][]descpb.FamilyID {
+	ret := make(map[descpb.IndexID][]descpb.FamilyID, len(desc.AllIndexes()))
+	for _, idx := range desc.AllIndexes() {
+		ret[idx.GetID()] = IndexFamilyIDs(desc, idx)
+	}
+	return ret
+}
+
+// IndexFamilyIDs returns the IDs of the column families of the table for which
+// idx writes a KV entry, in family order. Primary indexes use all families.
+// Secondary indexes always use the first family, along with any other family
+// containing one of their stored columns, since column families only apply to
+// the value part of the KV.
+func IndexFamilyIDs(desc TableDescriptor, idx Index) []descpb.FamilyID {
+	families := desc.GetFamilies()
+	ret := make([]descpb.FamilyID, 0, len(families))
+	isPrimary := desc.GetPrimaryIndexID() == idx.GetID() ||
+		idx.GetEncodingType() == catenumpb.PrimaryIndexEncoding
+	storedColumnIDs := idx.CollectSecondaryStoredColumnIDs()
+	for i := range families {
+		family := &families[i]
+		if isPrimary || i == 0 {
+			ret = append(ret, family.ID)
+			continue
+		}
+		for _, colID := range family.ColumnIDs {
+			if storedColumnIDs.Contains(colID) {
+				ret = append(ret, family.ID)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// IndexRequiredByForeignKey returns true, along with the foreign key in
+// question, iff idx is the only non-dropped unique constraint in desc which
+// can serve as the referenced unique constraint of one of desc's inbound
+// foreign keys. Such an index can't be dropped without also dropping the
+// foreign key.
+func IndexRequiredByForeignKey(
+	desc TableDescriptor, idx Index,
+) (bool, *descpb.ForeignKeyConstraint) {
+	uwi := idx.AsUniqueWithIndex()
+	if uwi == nil {
+		return false, nil
+	}
+	hasOtherReferencedUniqueConstraint := func(fk ForeignKeyConstraint) bool {
+		for _, other := range desc.UniqueConstraintsWithIndex() {
+			if other.GetID() != idx.GetID() && !other.Dropped() &&
+				other.IsValidReferencedUniqueConstraint(fk) {
+				return true
+			}
+		}
+		for _, other := range desc.UniqueConstraintsWithoutIndex() {
+			if !other.Dropped() && other.IsValidReferencedUniqueConstraint(fk) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, fk := range desc.InboundForeignKeys() {
+		if uwi.IsValidReferencedUniqueConstraint(fk) && !hasOtherReferencedUniqueConstraint(fk) {
+			return true, fk.ForeignKeyDesc()
+		}
+	}
+	return false, nil
+}
+
+// ForEachKeyColumn applies f to each key column of idx in desc, in key order,
+// along with the column's direction in the index key. Returns an error if a key
+// column can't be found in desc. Supports iterutil.StopIteration.
+func ForEachKeyColumn(
+	desc TableDescriptor,
+	idx Index,
+	f func(col Column, dir catenumpb.IndexColumn_Direction) error,
+) error {
+	cols := desc.IndexKeyColumns(idx)
+	dirs := desc.IndexKeyColumnDirections(idx)
+	for i, col := range cols {
+		if col == nil {
+			return errors.AssertionFailedf("index %q key column ID %d not found in table %q",
+				idx.GetName(), idx.GetKeyColumnID(i), desc.GetName())
+		}
+		if err := f(col, dirs[i]); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	return nil
+}
+
+// ForEachComputedColumn applies f to each non-dropped computed column of the
+// table, both stored and virtual, in the order of NonDropColumns(). Supports
+// iterutil.StopIteration.
+func ForEachComputedColumn(desc TableDescriptor, f func(col Column) error) error {
+	for _, col := range desc.NonDropColumns() {
+		if !col.IsComputed() {
+			continue
+		}
+		if err := f(col); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	return nil
+}
+
+// ColumnsNotIndexed returns the public, non-virtual columns of the table which
+// don't appear in any non-dropped index, i.e. which are neither key columns of
+// any index nor stored in any secondary index. Columns which are merely stored
+// in the primary index are considered not indexed, since every non-virtual
+// column is.
+func ColumnsNotIndexed(desc TableDescriptor) []Column {
+	var indexed TableColSet
+	for _, idx := range desc.NonDropIndexes() {
+		indexed.UnionWith(idx.CollectKeyColumnIDs())
+		indexed.UnionWith(idx.CollectSecondaryStoredColumnIDs())
+	}
+	var ret []Column
+	for _, col := range desc.PublicColumns() {
+		if col.IsVirtual() || col.IsSystemColumn() || indexed.Contains(col.GetID()) {
+			continue
+		}
+		ret = append(ret, col)
+	}
+	return ret
+}
+
+// MutationStateName returns a human-readable name for the mutation state of
+// the table element, matching the names of the descpb.DescriptorMutation_State
+// values, or "PUBLIC" if the table element isn't actually in a mutation.
+func MutationStateName(m TableElementMaybeMutation) string {
+	switch {
+	case !m.IsMutation():
+		return "PUBLIC"
+	case m.DeleteOnly():
+		return "DELETE_ONLY"
+	case m.WriteAndDeleteOnly():
+		return "WRITE_ONLY"
+	case m.Backfilling():
+		return "BACKFILLING"
+	case m.Merging():
+		return "MERGING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// MutationsForID returns the mutations of the table which belong to the
+// mutation with the given ID, in the order of AllMutations().
+func MutationsForID(desc TableDescriptor, id descpb.MutationID) []Mutation {
+	var ret []Mutation
+	for _, m := range desc.AllMutations() {
+		if m.MutationID() == id {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+// BlockingMutationKinds returns human-readable descriptions of the kinds of the
+// in-flight mutations of the table, such as "add column" or "drop index", which
+// prevent another schema change from running on it. Each kind is listed once,
+// in the order of first occurrence in AllMutations(). Returns nil if the table
+// has no mutations.
+func BlockingMutationKinds(desc TableDescriptor) []string {
+	var ret []string
+	seen := make(map[string]struct{})
+	for _, m := range desc.AllMutations() {
+		kind := mutationKind(m)
+		if _, ok := seen[kind]; ok {
+			continue
+		}
+		seen[kind] = struct{}{}
+		ret = append(ret, kind)
+	}
+	return ret
+}
+
+// mutationKind returns a human-readable description of the kind of mutation m.
+func mutationKind(m Mutation) string {
+	var element string
+	switch {
+	case m.AsColumn() != nil:
+		element = "column"
+	case m.AsIndex() != nil:
+		element = "index"
+	case m.AsCheck() != nil:
+		element = "check constraint"
+	case m.AsForeignKey() != nil:
+		element = "foreign key constraint"
+	case m.AsUniqueWithoutIndex() != nil:
+		element = "unique constraint"
+	case m.AsPrimaryKeySwap() != nil:
+		return "primary key change"
+	case m.AsComputedColumnSwap() != nil:
+		return "computed column swap"
+	case m.AsMaterializedViewRefresh() != nil:
+		return "materialized view refresh"
+	case m.AsModifyRowLevelTTL() != nil:
+		return "row-level TTL change"
+	default:
+		return "unknown mutation"
+	}
+	if m.Dropped() {
+		return "drop " + element
+	}
+	return "add " + element
+}
+
+// HasPartialIndexes returns true iff any non-dropped index of the table is a
+// partial index.
+func HasPartialIndexes(desc TableDescriptor) bool {
+	return FindNonDropIndex(desc, func(idx Index) bool {
+		return idx.IsPartial()
+	}) != nil
+}
+
+// HasExpressionIndexes returns true iff any non-dropped index of the table has
+// a key column which represents an expression.
+func HasExpressionIndexes(desc TableDescriptor) bool {
+	return FindNonDropIndex(desc, func(idx Index) bool {
+		for _, col := range desc.IndexKeyColumns(idx) {
+			if col != nil && col.IsExpressionIndexColumn() {
+				return true
+			}
+		}
+		return false
+	}) != nil
+}
+
+// HasNotVisibleIndexes returns true iff any public secondary index of the table
+// is not visible to the optimizer, be it fully or partially.
+func HasNotVisibleIndexes(desc TableDescriptor) bool {
+	for _, idx := range desc.PublicNonPrimaryIndexes() {
+		if idx.IsNotVisible() {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatIndexKeyColumns returns the key columns of idx along with their
+// directions, formatted like "(a ASC, b DESC)". Expression index columns are
+// formatted as their parenthesized expression, as in SHOW CREATE, instead of
+// the name of the inaccessible virtual column which backs them.
+func FormatIndexKeyColumns(desc TableDescriptor, idx Index) string {
+	var sb strings.Builder
+	cols := desc.IndexKeyColumns(idx)
+	sb.WriteByte('(')
+	for i := 0; i < idx.NumKeyColumns(); i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if col := cols[i]; col != nil && col.IsExpressionIndexColumn() {
+			sb.WriteByte('(')
+			sb.WriteString(col.GetComputeExpr())
+			sb.WriteByte(')')
+		} else {
+			sb.WriteString(tree.NameString(idx.GetKeyColumnName(i)))
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(idx.GetKeyColumnDirection(i).String())
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// ValidateAsPrimaryKey returns an error if idx can't serve as the primary index
+// of desc because one of its key columns is nullable, inaccessible or virtual.
+// The shard column of a hash-sharded index is virtual by design and is allowed.
+func ValidateAsPrimaryKey(desc TableDescriptor, idx Index) error {
+	return ForEachKeyColumn(desc, idx, func(col Column, _ catenumpb.IndexColumn_Direction) error {
+		if col.IsNullable() {
+			return pgerror.Newf(pgcode.InvalidSchemaDefinition,
+				"cannot use nullable column %q in primary key", col.GetName())
+		}
+		if idx.IsSharded() && col.GetName() == idx.GetShardColumnName() {
+			return nil
+		}
+		if col.IsInaccessible() {
+			return pgerror.Newf(pgcode.InvalidSchemaDefinition,
+				"cannot use inaccessible column %q in primary key", col.GetName())
+		}
+		if col.IsVirtual() {
+			return pgerror.Newf(pgcode.InvalidSchemaDefinition,
+				"cannot use virtual column %q in primary key", col.GetName())
+		}
+		return nil
+	})
+}
+
+// ValidateVirtualColumnIndexing returns an error if any non-dropped index of
+// the table stores a virtual column. Virtual columns have no value to store;
+// they may only be key columns of secondary indexes.
+func ValidateVirtualColumnIndexing(desc TableDescriptor) error {
+	for _, idx := range desc.NonDropIndexes() {
+		numStored := idx.NumPrimaryStoredColumns() + idx.NumSecondaryStoredColumns()
+		for i := 0; i < numStored; i++ {
+			col, err := MustFindColumnByID(desc, idx.GetStoredColumnID(i))
+			if err != nil {
+				return err
+			}
+			if col.IsVirtual() {
+				return pgerror.Newf(pgcode.InvalidSchemaDefinition,
+					"index %q cannot store virtual column %q", idx.GetName(), col.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateUniqueColumnNames returns an error naming the first column of the
+// table whose name is already used by another of its public or mutation
+// columns. Columns being dropped are ignored, since a column may be dropped
+// and another one added with the same name in the same transaction.
+func ValidateUniqueColumnNames(desc TableDescriptor) error {
+	names := make(map[string]struct{}, len(desc.NonDropColumns()))
+	for _, col := range desc.NonDropColumns() {
+		if _, ok := names[col.GetName()]; ok {
+			return pgerror.Newf(pgcode.DuplicateColumn,
+				"duplicate column name: %q", col.GetName())
+		}
+		names[col.GetName()] = struct{}{}
+	}
+	return nil
+}
+
+// canonicalIndex is the structure which IndexToCanonicalJSON serializes. The
+// JSON fields are emitted in the order in which they're declared here.
+type canonicalIndex struct {
+	Type                string            `json:"type"`
+	Unique              bool              `json:"unique"`
+	KeyColumnIDs        []descpb.ColumnID `json:"key_column_ids"`
+	KeyColumnDirections []string          `json:"key_column_directions"`
+	KeySuffixColumnIDs  []descpb.ColumnID `json:"key_suffix_column_ids"`
+	StoredColumnIDs     []descpb.ColumnID `json:"stored_column_ids"`
+	CompositeColumnIDs  []descpb.ColumnID `json:"composite_column_ids"`
+	Predicate           string            `json:"predicate"`
+	// InvertedColumnKind is only set for inverted indexes.
+	InvertedColumnKind string         `json:"inverted_column_kind,omitempty"`
+	Sharded            canonicalShard `json:"sharded"`
+	GeoConfig          geopb.Config   `json:"geo_config"`
+	Invisibility       float64        `json:"invisibility"`
+}
+
+// canonicalShard is the part of catpb.ShardedDescriptor which
+// IndexToCanonicalJSON serializes. The names of the shard column and of the
+// columns it's computed over are replaced by the IDs of the latter, since
+// they're rewritten when those columns are renamed.
+type canonicalShard struct {
+	IsSharded    bool              `json:"is_sharded"`
+	ShardBuckets int32             `json:"shard_buckets"`
+	ColumnIDs    []descpb.ColumnID `json:"column_ids"`
+}
+
+// IndexToCanonicalJSON returns a deterministic JSON serialization of the
+// structure of idx, suitable for comparing indexes across descriptors. The
+// index name, ID and partitioning are omitted, and columns are only referred
+// to by ID, so renames don't affect the result. Stored and composite column IDs
+// are sorted, since their order is irrelevant.
+func IndexToCanonicalJSON(idx Index) ([]byte, error) {
+	ci, err := makeCanonicalIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ci)
+}
+
+func makeCanonicalIndex(idx Index) (canonicalIndex, error) {
+	shardColumnIDs, err := idx.ShardColumnIDs()
+	if err != nil {
+		return canonicalIndex{}, err
+	}
+	shard := canonicalShard{
+		IsSharded:    idx.IsSharded(),
+		ShardBuckets: idx.GetSharded().ShardBuckets,
+		ColumnIDs:    append([]descpb.ColumnID{}, shardColumnIDs...),
+	}
+	stored := idx.CollectPrimaryStoredColumnIDs()
+	stored.UnionWith(idx.CollectSecondaryStoredColumnIDs())
+	ci := canonicalIndex{
+		Type:                idx.GetType().String(),
+		Unique:              idx.IsUnique(),
+		KeyColumnIDs:        make([]descpb.ColumnID, idx.NumKeyColumns()),
+		KeyColumnDirections: make([]string, idx.NumKeyColumns()),
+		KeySuffixColumnIDs:  make([]descpb.ColumnID, idx.NumKeySuffixColumns()),
+		StoredColumnIDs:     append([]descpb.ColumnID{}, stored.Ordered()...),
+		CompositeColumnIDs:  append([]descpb.ColumnID{}, idx.CollectCompositeColumnIDs().Ordered()...),
+		Predicate:           idx.GetPredicate(),
+		Sharded:             shard,
+		GeoConfig:           idx.GetGeoConfig(),
+		Invisibility:        idx.GetInvisibility(),
+	}
+	if idx.GetType() == descpb.IndexDescriptor_INVERTED {
+		ci.InvertedColumnKind = idx.InvertedColumnKind().String()
+	}
+	for i := range ci.KeyColumnIDs {
+		ci.KeyColumnIDs[i] = idx.GetKeyColumnID(i)
+		ci.KeyColumnDirections[i] = idx.GetKeyColumnDirection(i).String()
+	}
+	for i := range ci.KeySuffixColumnIDs {
+		ci.KeySuffixColumnIDs[i] = idx.GetKeySuffixColumnID(i)
+	}
+	return ci, nil
+}
+
+// DiffIndexes compares the non-dropped indexes of two versions of a table
+// descriptor, matching them by ID. It returns the indexes of after which aren't
+// in before, the indexes of before which aren't in after, and the indexes of
+// after whose structure differs from that of their counterpart in before, as
+// determined by comparing their IndexToCanonicalJSON serializations. Renames of
+// indexes or columns alone are not reported as changes. An index which can't be
+// serialized, because its descriptor is corrupt, is reported as changed.
+func DiffIndexes(before, after TableDescriptor) (added, dropped, changed []Index) {
+	beforeByID := make(map[descpb.IndexID]Index, len(before.NonDropIndexes()))
+	for _, idx := range before.NonDropIndexes() {
+		beforeByID[idx.GetID()] = idx
+	}
+	for _, idx := range after.NonDropIndexes() {
+		prev, ok := beforeByID[idx.GetID()]
+		if !ok {
+			added = append(added, idx)
+			continue
+		}
+		delete(beforeByID, idx.GetID())
+		prevJSON, prevErr := IndexToCanonicalJSON(prev)
+		idxJSON, idxErr := IndexToCanonicalJSON(idx)
+		if prevErr != nil || idxErr != nil || !bytes.Equal(prevJSON, idxJSON) {
+			changed = append(changed, idx)
+		}
+	}
+	for _, idx := range before.NonDropIndexes() {
+		if _, ok := beforeByID[idx.GetID()]; ok {
+			dropped = append(dropped, idx)
+		}
+	}
+	return added, dropped, changed
+}
+
+// canonicalSchema is the structure which SchemaFingerprint hashes.
+type canonicalSchema struct {
+	Columns []canonicalColumn      `json:"columns"`
+	Indexes []canonicalSchemaIndex `json:"indexes"`
+}
+
+type canonicalColumn struct {
+	ID       descpb.ColumnID `json:"id"`
+	Type     string          `json:"type"`
+	Nullable bool            `json:"nullable"`
+}
+
+type canonicalSchemaIndex struct {
+	ID descpb.IndexID `json:"id"`
+	canonicalIndex
+}
+
+// SchemaFingerprint returns a hash of the structure of the non-dropped columns
+// and indexes of the table, suitable for detecting schema drift. The hash
+// covers the ID, type and nullability of each column and the ID and canonical
+// structure of each index, as defined by IndexToCanonicalJSON, visited in ID
+// order. Names, timestamps and descriptor versions are ignored, so two tables
+// which differ only in those properties have the same fingerprint.
+func SchemaFingerprint(desc TableDescriptor) (uint64, error) {
+	var cs canonicalSchema
+	for _, col := range desc.NonDropColumns() {
+		if !col.HasType() {
+			return 0, errors.AssertionFailedf(
+				"column %q (%d) of table %q has no type", col.GetName(), col.GetID(), desc.GetName())
+		}
+		cs.Columns = append(cs.Columns, canonicalColumn{
+			ID:       col.GetID(),
+			Type:     col.GetType().DebugString(),
+			Nullable: col.IsNullable(),
+		})
+	}
+	sort.Slice(cs.Columns, func(i, j int) bool {
+		return cs.Columns[i].ID < cs.Columns[j].ID
+	})
+	for _, idx := range desc.NonDropIndexes() {
+		ci, err := makeCanonicalIndex(idx)
+		if err != nil {
+			return 0, err
+		}
+		cs.Indexes = append(cs.Indexes, canonicalSchemaIndex{
+			ID:             idx.GetID(),
+			canonicalIndex: ci,
+		})
+	}
+	sort.Slice(cs.Indexes, func(i, j int) bool {
+		return cs.Indexes[i].ID < cs.Indexes[j].ID
+	})
+	buf, err := json.Marshal(cs)
+	if err != nil {
+		return 0, errors.Wrapf(err, "computing schema fingerprint of table %q", desc.GetName())
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(buf)
+	return h.Sum64(), nil
+}
+
+// ColumnsWithDefaults returns the writable columns of the table which may be
+// assigned a value implicitly when a row is written: those with a default
+// expression, with an on update expression, or which are identity columns.
+func ColumnsWithDefaults(desc TableDescriptor) []Column {
+	var ret []Column
+	for _, col := range desc.WritableColumns() {
+		if col.HasDefault() || col.HasOnUpdate() || col.IsGeneratedAsIdentity() {
+			ret = append(ret, col)
+		}
+	}
+	return ret
+}
+
+// ColumnsHaveIdenticalType returns true iff the columns a and b have identical
+// types as per types.T.Identical, which accounts for type modifiers such as
+// widths and precisions, and for collation locales. User-defined types must
+// also have been hydrated from the same version of their type descriptor.
+func ColumnsHaveIdenticalType(a, b Column) bool {
+	if !a.HasType() || !b.HasType() {
+		return false
+	}
+	ta, tb := a.GetType(), b.GetType()
+	return ta.Identical(tb) && ta.TypeMeta.Version == tb.TypeMeta.Version
+}
+
+// NotNullColumnIDs returns the IDs of the public columns of the table which
+// can't be NULL, either because they're explicitly marked as NOT NULL or
+// because they're key columns of the primary index.
+func NotNullColumnIDs(desc TableDescriptor) TableColSet {
+	ret := desc.GetPrimaryIndex().CollectKeyColumnIDs()
+	for _, col := range desc.PublicColumns() {
+		if !col.IsNullable() {
+			ret.Add(col.GetID())
+		}
+	}
+	return ret
+}
+
+// ForEachUniqueConstraint applies f to each non-dropped unique constraint of
+// the table, first those backed by a unique index, including the primary
+// index, then those without an index. The column IDs passed to f are those of
+// the constraint's key columns, in order. Supports iterutil.StopIteration.
+func ForEachUniqueConstraint(
+	desc TableDescriptor,
+	f func(name string, colIDs descpb.ColumnIDs, indexBacked bool) error,
+) error {
+	apply := func(c UniqueConstraint, indexBacked bool) error {
+		if c.Dropped() {
+			return nil
+		}
+		colIDs := make(descpb.ColumnIDs, c.NumKeyColumns())
+		for i := range colIDs {
+			colIDs[i] = c.GetKeyColumnID(i)
+		}
+		return f(c.GetName(), colIDs, indexBacked)
+	}
+	for _, c := range desc.UniqueConstraintsWithIndex() {
+		if err := apply(c, true /* indexBacked */); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	for _, c := range desc.UniqueConstraintsWithoutIndex() {
+		if err := apply(c, false /* indexBacked */); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	return nil
+}
+
+// FindIndexForUniqueConstraintName returns the index which enforces the
+// non-dropped unique constraint with the given name, including the primary key
+// constraint, and true. Returns false if there is no such constraint or if it
+// isn't backed by an index.
+func FindIndexForUniqueConstraintName(desc TableDescriptor, name string) (Index, bool) {
+	for _, c := range desc.UniqueConstraintsWithIndex() {
+		if !c.Dropped() && c.GetName() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// ForEachCheckConstraint applies f to the descriptor of each check constraint
+// of the table, regardless of its validity, including those which are still
+// being added or dropped by a mutation. Supports iterutil.StopIteration.
+func ForEachCheckConstraint(
+	desc TableDescriptor, f func(ck *descpb.TableDescriptor_CheckConstraint) error,
+) error {
+	for _, ck := range desc.CheckConstraints() {
+		if err := f(ck.CheckDesc()); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	return nil
+}
+
+// ColumnsInCheckConstraints returns the IDs of the columns referenced by any
+// check constraint of the table, including those which are still being added
+// or dropped by a mutation. An error is returned if a check constraint
+// references a column which doesn't exist in the table.
+func ColumnsInCheckConstraints(desc TableDescriptor) (TableColSet, error) {
+	var ret TableColSet
+	for _, ck := range desc.CheckConstraints() {
+		for i, n := 0, ck.NumReferencedColumns(); i < n; i++ {
+			colID := ck.GetReferencedColumnID(i)
+			if FindColumnByID(desc, colID) == nil {
+				return TableColSet{}, errors.AssertionFailedf(
+					"check constraint %q of table %q references unknown column %d",
+					ck.GetName(), desc.GetName(), colID)
+			}
+			ret.Add(colID)
+		}
+	}
+	return ret, nil
+}
+
+// ForEachForeignKeyReferencingColumn applies f to the descriptor of each
+// foreign key of the table involving the column with the given ID: outbound
+// foreign keys for which it's an origin column, and inbound foreign keys for
+// which it's a referenced column, with inbound set to true. A self-referencing
+// foreign key is thus visited twice if the column is on both sides. Supports
+// iterutil.StopIteration.
+func ForEachForeignKeyReferencingColumn(
+	desc TableDescriptor,
+	colID descpb.ColumnID,
+	f func(fk *descpb.ForeignKeyConstraint, inbound bool) error,
+) error {
+	for _, fk := range desc.OutboundForeignKeys() {
+		if fkDesc := fk.ForeignKeyDesc(); fkDesc.OriginColumnIDs.Contains(colID) {
+			if err := f(fkDesc, false /* inbound */); err != nil {
+				return iterutil.Map(err)
+			}
+		}
+	}
+	for _, fk := range desc.InboundForeignKeys() {
+		if fkDesc := fk.ForeignKeyDesc(); fkDesc.ReferencedColumnIDs.Contains(colID) {
+			if err := f(fkDesc, true /* inbound */); err != nil {
+				return iterutil.Map(err)
+			}
+		}
+	}
+	return nil
+}
+
+// ConstrainedKeyPrefixLength returns the number of leading key columns of idx
+// which are all in equalityCols, i.e. the length of the key prefix which is
+// fully constrained by equalities on these columns.
+func ConstrainedKeyPrefixLength(idx Index, equalityCols TableColSet) int {
+	for i := 0; i < idx.NumKeyColumns(); i++ {
+		if !equalityCols.Contains(idx.GetKeyColumnID(i)) {
+			return i
+		}
+	}
+	return idx.NumKeyColumns()
+}
+
+// IndexProvidesOrdering returns true iff scanning idx, either forwards or in
+// reverse, returns rows ordered by cols in the corresponding directions dirs,
+// i.e. iff cols is a prefix of the key columns of idx and dirs either all match
+// or all oppose the directions of these key columns. An empty ordering is
+// always provided.
+func IndexProvidesOrdering(
+	idx Index, cols []descpb.ColumnID, dirs []catenumpb.IndexColumn_Direction,
+) bool {
+	if len(cols) != len(dirs) || len(cols) > idx.NumKeyColumns() {
+		return false
+	}
+	forward, reverse := true, true
+	for i, colID := range cols {
+		if idx.GetKeyColumnID(i) != colID {
+			return false
+		}
+		if idx.GetKeyColumnDirection(i) == dirs[i] {
+			reverse = false
+		} else {
+			forward = false
+		}
+	}
+	return forward || reverse
+}
+
+// HasSelfReferentialForeignKey returns true iff any outbound foreign key of the
+// table references the table itself.
+func HasSelfReferentialForeignKey(desc TableDescriptor) bool {
+	for _, fk := range desc.OutboundForeignKeys() {
+		if fk.GetReferencedTableID() == desc.GetID() {
+			return true
+		}
+	}
+	return false
+}
+
+// PrimaryKeyHasComputedColumns returns true iff any key column of the primary
+// index of the table is a computed column, such as the shard column of a
+// hash-sharded primary key.
+func PrimaryKeyHasComputedColumns(desc TableDescriptor) bool {
+	for _, col := range desc.IndexKeyColumns(desc.GetPrimaryIndex()) {
+		if col != nil && col.IsComputed() {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexBefore returns true iff a precedes b in the canonical order of the
+// indexes of their table descriptor, as defined by Index.Ordinal. It's meant
+// to be used as a less function when sorting slices of indexes.
+func IndexBefore(a, b Index) bool {
+	return a.Ordinal() < b.Ordinal()
+}
+
+// IndexKeyPrefixLength returns the length in bytes of the /Table/<id>/<indexID>
+// prefix shared by all the KV keys of the index, as encoded by codec.
+func IndexKeyPrefixLength(codec keys.SQLCodec, desc TableDescriptor, idx Index) int {
+	return len(codec.IndexPrefix(uint32(desc.GetID()), uint32(idx.GetID())))
+}
+
+// IndexesSharingColumn returns the non-dropped indexes of the table, primary
+// index first, which contain the column with the given ID as a key, key suffix
+// or stored column. The primary index contains every non-virtual column.
+func IndexesSharingColumn(desc TableDescriptor, colID descpb.ColumnID) []Index {
+	var ret []Index
+	for _, idx := range desc.NonDropIndexes() {
+		if idx.ReadableColumnIDs(desc).Contains(colID) {
+			ret = append(ret, idx)
+		}
+	}
+	return ret
+}
+
+// IndexesAffectedByColumnTypeChange returns the non-dropped indexes of the
+// table, primary index first, whose encoding depends on the type of the column
+// with the given ID because it's one of their key, key suffix or stored
+// columns. These indexes must be rebuilt if the type of the column changes.
+func IndexesAffectedByColumnTypeChange(desc TableDescriptor, colID descpb.ColumnID) []Index {
+	var ret []Index
+	for _, idx := range desc.NonDropIndexes() {
+		if idx.CollectKeyColumnIDs().Contains(colID) ||
+			idx.CollectKeySuffixColumnIDs().Contains(colID) ||
+			idx.HasStoredColumn(colID) {
+			ret = append(ret, idx)
+		}
+	}
+	return ret
+}
+
+// SecondaryIndexKeySuffixMap returns the key suffix columns of each
+// non-dropped secondary index of the table, keyed by index ID. These are the
+// primary key columns which aren't in the index key, and which are appended to
+// it to uniquely identify rows, or only stored in the index value if the index
+// is unique and its key isn't NULL.
+func SecondaryIndexKeySuffixMap(desc TableDescriptor) map[descpb.IndexID]descpb.ColumnIDs {
+	ret := make(map[descpb.IndexID]descpb.ColumnIDs)
+	for _, idx := range desc.NonDropIndexes() {
+		if idx.Primary() {
+			continue
+		}
+		suffix := make(descpb.ColumnIDs, idx.NumKeySuffixColumns())
+		for i := range suffix {
+			suffix[i] = idx.GetKeySuffixColumnID(i)
+		}
+		ret[idx.GetID()] = suffix
+	}
+	return ret
+}
+
+// IndexesRequiringRewriteForNewPK returns the non-dropped secondary indexes of
+// the table whose key suffix columns would change if the primary key of the
+// table were made up of the key columns newPKColIDs. The key suffix of a
+// secondary index is made up of the primary key columns which aren't in its
+// key, so for instance an index whose key already contains both the old and the
+// new primary key columns needs no rewrite. Indexes which don't use the
+// secondary index encoding, such as the new primary index of an in-progress
+// primary key change, have no key suffix and are skipped. For indexes with
+// stored columns in the old format, which are kept among the key suffix
+// columns, only the remaining key suffix columns are compared.
+func IndexesRequiringRewriteForNewPK(desc TableDescriptor, newPKColIDs descpb.ColumnIDs) []Index {
+	var ret []Index
+	for _, idx := range desc.NonDropIndexes() {
+		if idx.GetEncodingType() != catenumpb.SecondaryIndexEncoding {
+			continue
+		}
+		keySuffix := descpb.ColumnIDs(idx.IndexDesc().KeySuffixColumnIDs)
+		if idx.HasOldStoredColumns() {
+			var oldStored TableColSet
+			for _, name := range idx.IndexDesc().StoreColumnNames {
+				if col := FindColumnByName(desc, name); col != nil {
+					oldStored.Add(col.GetID())
+				}
+			}
+			keySuffix = nil
+			for _, colID := range idx.IndexDesc().KeySuffixColumnIDs {
+				if !oldStored.Contains(colID) {
+					keySuffix = append(keySuffix, colID)
+				}
+			}
+		}
+		keyColIDs := idx.CollectKeyColumnIDs()
+		var newKeySuffix descpb.ColumnIDs
+		for _, colID := range newPKColIDs {
+			if !keyColIDs.Contains(colID) {
+				newKeySuffix = append(newKeySuffix, colID)
+				keyColIDs.Add(colID)
+			}
+		}
+		if !newKeySuffix.Equals(keySuffix) {
+			ret = append(ret, idx)
+		}
+	}
+	return ret
+}
+
+// AllSequenceIDsUsedByColumns returns the IDs of the sequences owned by any
+// column of the table, including columns being added or dropped, along with
+// the IDs of the sequences used by any of these columns, e.g. in their default
+// expressions. Both slices are deduplicated and in increasing order.
+func AllSequenceIDsUsedByColumns(desc TableDescriptor) (owned, used []descpb.ID) {
+	var ownedSet, usedSet DescriptorIDSet
+	for _, col := range desc.AllColumns() {
+		for i := 0; i < col.NumOwnsSequences(); i++ {
+			ownedSet.Add(col.GetOwnsSequenceID(i))
+		}
+		for i := 0; i < col.NumUsesSequences(); i++ {
+			usedSet.Add(col.GetUsesSequenceID(i))
+		}
+	}
+	return ownedSet.Ordered(), usedSet.Ordered()
+}
@@ -0,0 +1,2060 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package catalog_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/iterutil"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTestColumns returns nullable INT column descriptors with IDs starting
+// at 1, named after the given names.
+func makeTestColumns(names ...string) []descpb.ColumnDescriptor {
+	cols := make([]descpb.ColumnDescriptor, len(names))
+	for i, name := range names {
+		cols[i] = descpb.ColumnDescriptor{
+			ID:       descpb.ColumnID(i + 1),
+			Name:     name,
+			Type:     types.Int,
+			Nullable: true,
+		}
+	}
+	return cols
+}
+
+// makeTestIndex returns an index descriptor with ascending key columns.
+func makeTestIndex(
+	id descpb.IndexID, name string, keyColumnIDs ...descpb.ColumnID,
+) descpb.IndexDescriptor {
+	dirs := make([]catenumpb.IndexColumn_Direction, len(keyColumnIDs))
+	for i := range dirs {
+		dirs[i] = catenumpb.IndexColumn_ASC
+	}
+	return descpb.IndexDescriptor{
+		ID:                  id,
+		Name:                name,
+		KeyColumnIDs:        keyColumnIDs,
+		KeyColumnDirections: dirs,
+		Version:             descpb.LatestIndexDescriptorVersion,
+	}
+}
+
+// buildTestTable fills in the column names of the indexes in desc and returns
+// the corresponding immutable table descriptor.
+func buildTestTable(desc descpb.TableDescriptor) catalog.TableDescriptor {
+	if desc.ID == 0 {
+		desc.ID = 100
+	}
+	if desc.Name == "" {
+		desc.Name = "t"
+	}
+	names := make(map[descpb.ColumnID]string, len(desc.Columns))
+	for _, col := range desc.Columns {
+		names[col.ID] = col.Name
+	}
+	fillNames := func(idx *descpb.IndexDescriptor) {
+		idx.KeyColumnNames = make([]string, len(idx.KeyColumnIDs))
+		for i, id := range idx.KeyColumnIDs {
+			idx.KeyColumnNames[i] = names[id]
+		}
+		if len(idx.StoreColumnIDs) > 0 {
+			idx.StoreColumnNames = make([]string, len(idx.StoreColumnIDs))
+			for i, id := range idx.StoreColumnIDs {
+				idx.StoreColumnNames[i] = names[id]
+			}
+		}
+	}
+	fillNames(&desc.PrimaryIndex)
+	for i := range desc.Indexes {
+		fillNames(&desc.Indexes[i])
+	}
+	return tabledesc.NewBuilder(&desc).BuildImmutableTable()
+}
+
+// makeShardedTestTable returns a table with columns a and b, a primary index
+// on a, and a secondary index t_b_shard hash sharded on b into 4 buckets.
+func makeShardedTestTable() catalog.TableDescriptor {
+	cols := makeTestColumns("a", "b", "crdb_internal_b_shard_4")
+	shardExpr := "mod(fnv32(crdb_internal.datums_to_bytes(b)), 4:::INT8)"
+	cols[2].ComputeExpr = &shardExpr
+	cols[2].Virtual = true
+	cols[2].Hidden = true
+	sharded := makeTestIndex(2, "t_b_shard", 3, 2)
+	sharded.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	sharded.Sharded = catpb.ShardedDescriptor{
+		IsSharded:    true,
+		Name:         "crdb_internal_b_shard_4",
+		ShardBuckets: 4,
+		ColumnNames:  []string{"b"},
+	}
+	return buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes:      []descpb.IndexDescriptor{sharded},
+	})
+}
+
+// renameTestColumn returns a copy of desc in which the column named from is
+// renamed to to, along with any shard columns computed over it.
+func renameTestColumn(
+	t *testing.T, desc catalog.TableDescriptor, from, to string,
+) catalog.TableDescriptor {
+	mut := tabledesc.NewBuilder(desc.TableDesc()).BuildExistingMutableTable()
+	col, err := catalog.MustFindColumnByName(mut, from)
+	require.NoError(t, err)
+	require.NoError(t, tabledesc.RenameColumnInTable(
+		mut, col, tree.Name(to), nil, /* isShardColumnRenameable */
+	))
+	return mut.ImmutableCopy().(catalog.TableDescriptor)
+}
+
+func TestValidatePrimaryIndexNoKeySuffix(t *testing.T) {
+	valid := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	})
+	require.NoError(t, catalog.ValidatePrimaryIndexNoKeySuffix(valid))
+
+	corruptPK := makeTestIndex(1, "t_pkey", 1)
+	corruptPK.KeySuffixColumnIDs = []descpb.ColumnID{2}
+	corrupt := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b"),
+		PrimaryIndex: corruptPK,
+	})
+	require.Error(t, catalog.ValidatePrimaryIndexNoKeySuffix(corrupt))
+}
+
+func TestRedundantPrefixIndexes(t *testing.T) {
+	unique := makeTestIndex(5, "t_d_unique", 4)
+	unique.Unique = true
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d", "e"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			makeTestIndex(2, "t_b", 2),
+			makeTestIndex(3, "t_b_c", 2, 3),
+			makeTestIndex(4, "t_b_c_d", 2, 3, 4),
+			unique,
+			makeTestIndex(6, "t_d_e", 4, 5),
+			makeTestIndex(7, "t_e", 5),
+		},
+	})
+	require.Equal(t, map[descpb.IndexID]descpb.IndexID{
+		2: 3,
+		3: 4,
+	}, catalog.RedundantPrefixIndexes(desc))
+}
+
+func TestIndexFamilyUsage(t *testing.T) {
+	storing := makeTestIndex(3, "t_b_storing", 2)
+	storing.StoreColumnIDs = []descpb.ColumnID{3, 5}
+	storingSameFamily := makeTestIndex(4, "t_c_storing", 3)
+	storingSameFamily.StoreColumnIDs = []descpb.ColumnID{2}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d", "e"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			makeTestIndex(2, "t_b", 2),
+			storing,
+			storingSameFamily,
+		},
+		Families: []descpb.ColumnFamilyDescriptor{
+			{ID: 0, Name: "f0", ColumnIDs: []descpb.ColumnID{1, 2}},
+			{ID: 1, Name: "f1", ColumnIDs: []descpb.ColumnID{3, 4}},
+			{ID: 2, Name: "f2", ColumnIDs: []descpb.ColumnID{5}},
+		},
+	})
+	usage := catalog.IndexFamilyUsage(desc)
+	require.Equal(t, map[descpb.IndexID][]descpb.FamilyID{
+		1: {0, 1, 2},
+		2: {0},
+		3: {0, 1, 2},
+		4: {0},
+	}, usage)
+	// Each index writes one KV entry per family it uses.
+	for _, idx := range desc.AllIndexes() {
+		require.Equal(t, len(usage[idx.GetID()]), desc.IndexKeysPerRow(idx), idx.GetName())
+	}
+}
+
+func TestFamilyForColumn(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d", "v")
+	computeExpr := "a + 1:::INT8"
+	cols[4].Virtual = true
+	cols[4].ComputeExpr = &computeExpr
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Families: []descpb.ColumnFamilyDescriptor{
+			{ID: 0, Name: "f0", ColumnIDs: []descpb.ColumnID{1, 2}},
+			{ID: 1, Name: "f1", ColumnIDs: []descpb.ColumnID{3}},
+			{ID: 3, Name: "f3", ColumnIDs: []descpb.ColumnID{4}},
+		},
+	})
+
+	for _, tc := range []struct {
+		colID    descpb.ColumnID
+		expected descpb.FamilyID
+		ok       bool
+	}{
+		{colID: 1, expected: 0, ok: true},
+		{colID: 2, expected: 0, ok: true},
+		{colID: 3, expected: 1, ok: true},
+		{colID: 4, expected: 3, ok: true},
+		// Virtual columns don't belong to any family.
+		{colID: 5, ok: false},
+		// Unknown columns neither.
+		{colID: 6, ok: false},
+	} {
+		t.Run(fmt.Sprint(tc.colID), func(t *testing.T) {
+			id, ok := catalog.FamilyForColumn(desc, tc.colID)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, id)
+		})
+	}
+}
+
+func TestIndexRequiredByForeignKey(t *testing.T) {
+	uniqueB := makeTestIndex(2, "t_b_key", 2)
+	uniqueB.Unique = true
+	uniqueC := makeTestIndex(3, "t_c_key", 3)
+	uniqueC.Unique = true
+	uniqueCDup := makeTestIndex(4, "t_c_key2", 3)
+	uniqueCDup.Unique = true
+	uniqueD := makeTestIndex(5, "t_d_key", 4)
+	uniqueD.Unique = true
+	fkOnB := descpb.ForeignKeyConstraint{
+		Name:                "fk_b",
+		OriginTableID:       200,
+		OriginColumnIDs:     []descpb.ColumnID{1},
+		ReferencedTableID:   100,
+		ReferencedColumnIDs: []descpb.ColumnID{2},
+	}
+	fkOnC := descpb.ForeignKeyConstraint{
+		Name:                "fk_c",
+		OriginTableID:       200,
+		OriginColumnIDs:     []descpb.ColumnID{2},
+		ReferencedTableID:   100,
+		ReferencedColumnIDs: []descpb.ColumnID{3},
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes:      []descpb.IndexDescriptor{uniqueB, uniqueC, uniqueCDup, uniqueD},
+		InboundFKs:   []descpb.ForeignKeyConstraint{fkOnB, fkOnC},
+	})
+
+	for _, tc := range []struct {
+		index      string
+		expectedFK string
+	}{
+		// The only unique index backing fk_b.
+		{"t_b_key", "fk_b"},
+		// Either of these can back fk_c.
+		{"t_c_key", ""},
+		{"t_c_key2", ""},
+		// Not referenced by any foreign key.
+		{"t_d_key", ""},
+		{"t_pkey", ""},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			required, fk := catalog.IndexRequiredByForeignKey(desc, idx)
+			require.Equal(t, tc.expectedFK != "", required)
+			if required {
+				require.Equal(t, tc.expectedFK, fk.Name)
+			} else {
+				require.Nil(t, fk)
+			}
+		})
+	}
+}
+
+func TestForEachKeyColumn(t *testing.T) {
+	composite := makeTestIndex(2, "t_c_b_a", 3, 2, 1)
+	composite.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1, 2),
+		Indexes:      []descpb.IndexDescriptor{composite},
+	})
+	idx, err := catalog.MustFindIndexByName(desc, "t_c_b_a")
+	require.NoError(t, err)
+
+	var names []string
+	var dirs []catenumpb.IndexColumn_Direction
+	require.NoError(t, catalog.ForEachKeyColumn(desc, idx, func(
+		col catalog.Column, dir catenumpb.IndexColumn_Direction,
+	) error {
+		names = append(names, col.GetName())
+		dirs = append(dirs, dir)
+		return nil
+	}))
+	require.Equal(t, []string{"c", "b", "a"}, names)
+	require.Equal(t, []catenumpb.IndexColumn_Direction{
+		catenumpb.IndexColumn_ASC, catenumpb.IndexColumn_DESC, catenumpb.IndexColumn_ASC,
+	}, dirs)
+
+	// Check that iteration can be stopped early.
+	names = names[:0]
+	require.NoError(t, catalog.ForEachKeyColumn(desc, idx, func(
+		col catalog.Column, _ catenumpb.IndexColumn_Direction,
+	) error {
+		names = append(names, col.GetName())
+		if len(names) == 2 {
+			return iterutil.StopIteration()
+		}
+		return nil
+	}))
+	require.Equal(t, []string{"c", "b"}, names)
+}
+
+func TestColumnsNotIndexed(t *testing.T) {
+	columnNames := func(cols []catalog.Column) []string {
+		var names []string
+		for _, col := range cols {
+			names = append(names, col.GetName())
+		}
+		return names
+	}
+
+	t.Run("partially indexed", func(t *testing.T) {
+		cols := makeTestColumns("a", "b", "c", "d", "e", "v")
+		v := "a + 1"
+		cols[5].ComputeExpr = &v
+		cols[5].Virtual = true
+		storing := makeTestIndex(2, "t_b", 2)
+		storing.StoreColumnIDs = []descpb.ColumnID{3}
+		pk := makeTestIndex(1, "t_pkey", 1)
+		pk.StoreColumnIDs = []descpb.ColumnID{2, 3, 4, 5}
+		desc := buildTestTable(descpb.TableDescriptor{
+			Columns:      cols,
+			PrimaryIndex: pk,
+			Indexes:      []descpb.IndexDescriptor{storing},
+		})
+		require.Equal(t, []string{"d", "e"}, columnNames(catalog.ColumnsNotIndexed(desc)))
+	})
+
+	t.Run("fully indexed", func(t *testing.T) {
+		desc := buildTestTable(descpb.TableDescriptor{
+			Columns:      makeTestColumns("a", "b"),
+			PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+			Indexes:      []descpb.IndexDescriptor{makeTestIndex(2, "t_b", 2)},
+		})
+		require.Empty(t, catalog.ColumnsNotIndexed(desc))
+	})
+}
+
+func TestMutationStateName(t *testing.T) {
+	states := []descpb.DescriptorMutation_State{
+		descpb.DescriptorMutation_DELETE_ONLY,
+		descpb.DescriptorMutation_WRITE_ONLY,
+		descpb.DescriptorMutation_BACKFILLING,
+		descpb.DescriptorMutation_MERGING,
+	}
+	var mutations []descpb.DescriptorMutation
+	for i, state := range states {
+		idx := makeTestIndex(descpb.IndexID(i+2), state.String(), 2)
+		mutations = append(mutations, descpb.DescriptorMutation{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &idx},
+			State:       state,
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  1,
+		})
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Mutations:    mutations,
+	})
+	names := []string{catalog.MutationStateName(desc.GetPrimaryIndex())}
+	for _, m := range desc.AllMutations() {
+		names = append(names, catalog.MutationStateName(m))
+	}
+	require.Equal(t, []string{"PUBLIC", "DELETE_ONLY", "WRITE_ONLY", "BACKFILLING", "MERGING"}, names)
+}
+
+func TestMutationColumnAndIndex(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c")
+	idx := makeTestIndex(2, "t_b", 2)
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols[:2],
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Mutations: []descpb.DescriptorMutation{
+			{
+				Descriptor_: &descpb.DescriptorMutation_Column{Column: &cols[2]},
+				State:       descpb.DescriptorMutation_DELETE_ONLY,
+				Direction:   descpb.DescriptorMutation_ADD,
+				MutationID:  1,
+			},
+			{
+				Descriptor_: &descpb.DescriptorMutation_Index{Index: &idx},
+				State:       descpb.DescriptorMutation_DELETE_ONLY,
+				Direction:   descpb.DescriptorMutation_ADD,
+				MutationID:  1,
+			},
+		},
+	})
+	mutations := desc.AllMutations()
+	require.Len(t, mutations, 2)
+
+	col, ok := mutations[0].Column()
+	require.True(t, ok)
+	require.Equal(t, "c", col.GetName())
+	idxMutation, ok := mutations[0].Index()
+	require.False(t, ok)
+	require.Nil(t, idxMutation)
+
+	index, ok := mutations[1].Index()
+	require.True(t, ok)
+	require.Equal(t, "t_b", index.GetName())
+	colMutation, ok := mutations[1].Column()
+	require.False(t, ok)
+	require.Nil(t, colMutation)
+}
+
+func TestBlockingMutationKinds(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d")
+	newPK := makeTestIndex(2, "t_pkey_new", 2)
+	droppedIdx := makeTestIndex(3, "t_c", 3)
+	check := descpb.TableDescriptor_CheckConstraint{
+		Name:         "check_b",
+		Expr:         "b > 0:::INT8",
+		ColumnIDs:    []descpb.ColumnID{2},
+		Validity:     descpb.ConstraintValidity_Validating,
+		ConstraintID: 2,
+	}
+	mutations := []descpb.DescriptorMutation{
+		{
+			Descriptor_: &descpb.DescriptorMutation_Column{Column: &cols[2]},
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  1,
+		},
+		{
+			Descriptor_: &descpb.DescriptorMutation_Column{Column: &cols[3]},
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  1,
+		},
+		{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &newPK},
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  2,
+		},
+		{
+			Descriptor_: &descpb.DescriptorMutation_PrimaryKeySwap{
+				PrimaryKeySwap: &descpb.PrimaryKeySwap{OldPrimaryIndexId: 1, NewPrimaryIndexId: 2},
+			},
+			Direction:  descpb.DescriptorMutation_ADD,
+			MutationID: 2,
+		},
+		{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &droppedIdx},
+			Direction:   descpb.DescriptorMutation_DROP,
+			MutationID:  3,
+		},
+		{
+			Descriptor_: &descpb.DescriptorMutation_Constraint{
+				Constraint: &descpb.ConstraintToUpdate{
+					ConstraintType: descpb.ConstraintToUpdate_CHECK,
+					Name:           check.Name,
+					Check:          check,
+				},
+			},
+			Direction:  descpb.DescriptorMutation_ADD,
+			MutationID: 4,
+		},
+	}
+	for i := range mutations {
+		mutations[i].State = descpb.DescriptorMutation_DELETE_ONLY
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols[:2],
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Mutations:    mutations,
+	})
+	require.Equal(t, []string{
+		"add column",
+		"add index",
+		"primary key change",
+		"drop index",
+		"add check constraint",
+	}, catalog.BlockingMutationKinds(desc))
+
+	require.Nil(t, catalog.BlockingMutationKinds(buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	})))
+}
+
+func TestMutationsForID(t *testing.T) {
+	var mutations []descpb.DescriptorMutation
+	// Interleave the mutations of two schema changes, with IDs 1 and 2.
+	for i, mutationID := range []descpb.MutationID{1, 2, 1, 2, 2} {
+		idx := makeTestIndex(descpb.IndexID(i+2), fmt.Sprintf("t_b_%d", i+2), 2)
+		mutations = append(mutations, descpb.DescriptorMutation{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &idx},
+			State:       descpb.DescriptorMutation_DELETE_ONLY,
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  mutationID,
+		})
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Mutations:    mutations,
+	})
+
+	for _, tc := range []struct {
+		id       descpb.MutationID
+		expected []string
+	}{
+		{id: 1, expected: []string{"t_b_2", "t_b_4"}},
+		{id: 2, expected: []string{"t_b_3", "t_b_5", "t_b_6"}},
+		{id: 3, expected: nil},
+	} {
+		t.Run(fmt.Sprint(tc.id), func(t *testing.T) {
+			var names []string
+			for _, m := range catalog.MutationsForID(desc, tc.id) {
+				require.Equal(t, tc.id, m.MutationID())
+				names = append(names, m.AsIndex().GetName())
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestHasPartialAndExpressionIndexes(t *testing.T) {
+	cols := makeTestColumns("a", "b", "crdb_internal_idx_expr")
+	expr := "a + b"
+	cols[2].ComputeExpr = &expr
+	cols[2].Virtual = true
+	cols[2].Inaccessible = true
+	partial := makeTestIndex(2, "t_a_partial", 1)
+	partial.Predicate = "b > 0:::INT8"
+
+	for _, tc := range []struct {
+		name                   string
+		indexes                []descpb.IndexDescriptor
+		hasPartial, hasExprIdx bool
+	}{
+		{
+			name:    "none",
+			indexes: []descpb.IndexDescriptor{makeTestIndex(2, "t_b", 2)},
+		},
+		{
+			name:       "partial",
+			indexes:    []descpb.IndexDescriptor{partial},
+			hasPartial: true,
+		},
+		{
+			name:       "expression",
+			indexes:    []descpb.IndexDescriptor{makeTestIndex(3, "t_expr", 3)},
+			hasExprIdx: true,
+		},
+		{
+			name:       "both",
+			indexes:    []descpb.IndexDescriptor{partial, makeTestIndex(3, "t_expr", 3)},
+			hasPartial: true,
+			hasExprIdx: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := buildTestTable(descpb.TableDescriptor{
+				Columns:      cols,
+				PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+				Indexes:      tc.indexes,
+			})
+			require.Equal(t, tc.hasPartial, catalog.HasPartialIndexes(desc))
+			require.Equal(t, tc.hasExprIdx, catalog.HasExpressionIndexes(desc))
+		})
+	}
+}
+
+func TestHasNotVisibleIndexes(t *testing.T) {
+	notVisible := makeTestIndex(3, "t_b_not_visible", 2)
+	notVisible.NotVisible = true
+	notVisible.Invisibility = 1.0
+	partiallyVisible := makeTestIndex(3, "t_b_partially_visible", 2)
+	partiallyVisible.NotVisible = true
+	partiallyVisible.Invisibility = 0.5
+
+	for _, tc := range []struct {
+		name     string
+		indexes  []descpb.IndexDescriptor
+		expected bool
+	}{
+		{name: "no secondary indexes", expected: false},
+		{
+			name:     "visible",
+			indexes:  []descpb.IndexDescriptor{makeTestIndex(2, "t_a", 1)},
+			expected: false,
+		},
+		{
+			name:     "not visible",
+			indexes:  []descpb.IndexDescriptor{makeTestIndex(2, "t_a", 1), notVisible},
+			expected: true,
+		},
+		{
+			name:     "partially visible",
+			indexes:  []descpb.IndexDescriptor{partiallyVisible},
+			expected: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := buildTestTable(descpb.TableDescriptor{
+				Columns:      makeTestColumns("a", "b"),
+				PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+				Indexes:      tc.indexes,
+			})
+			require.Equal(t, tc.expected, catalog.HasNotVisibleIndexes(desc))
+		})
+	}
+}
+
+func TestFormatIndexKeyColumns(t *testing.T) {
+	cols := makeTestColumns("a", "b", "crdb_internal_idx_expr", "Weird Name")
+	expr := "a + b"
+	cols[2].ComputeExpr = &expr
+	cols[2].Virtual = true
+	cols[2].Inaccessible = true
+	mixed := makeTestIndex(2, "t_a_b", 1, 2)
+	mixed.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	exprIdx := makeTestIndex(3, "t_expr_a", 3, 1)
+	exprIdx.KeyColumnDirections[0] = catenumpb.IndexColumn_DESC
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 4),
+		Indexes:      []descpb.IndexDescriptor{mixed, exprIdx},
+	})
+
+	for _, tc := range []struct {
+		index    string
+		expected string
+	}{
+		{index: "t_pkey", expected: `("Weird Name" ASC)`},
+		{index: "t_a_b", expected: "(a ASC, b DESC)"},
+		{index: "t_expr_a", expected: "((a + b) DESC, a ASC)"},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, catalog.FormatIndexKeyColumns(desc, idx))
+		})
+	}
+}
+
+func TestValidateAsPrimaryKey(t *testing.T) {
+	cols := makeTestColumns("a", "b", "v", "crdb_internal_a_shard_4")
+	cols[0].Nullable = false
+	expr := "a + 1:::INT8"
+	cols[2].ComputeExpr = &expr
+	cols[2].Virtual = true
+	cols[2].Nullable = false
+	shardExpr := "mod(fnv32(crdb_internal.datums_to_bytes(a)), 4:::INT8)"
+	cols[3].ComputeExpr = &shardExpr
+	cols[3].Virtual = true
+	cols[3].Hidden = true
+	cols[3].Nullable = false
+	sharded := makeTestIndex(5, "t_shard_a", 4, 1)
+	sharded.Sharded = catpb.ShardedDescriptor{
+		IsSharded:    true,
+		Name:         "crdb_internal_a_shard_4",
+		ShardBuckets: 4,
+		ColumnNames:  []string{"a"},
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			makeTestIndex(2, "t_a_b", 1, 2),
+			makeTestIndex(3, "t_v", 3),
+			makeTestIndex(4, "t_a", 1),
+			sharded,
+		},
+	})
+
+	for _, tc := range []struct {
+		index string
+		err   string
+	}{
+		{index: "t_pkey"},
+		{index: "t_a"},
+		{index: "t_shard_a"},
+		{index: "t_a_b", err: `cannot use nullable column "b" in primary key`},
+		{index: "t_v", err: `cannot use virtual column "v" in primary key`},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			err = catalog.ValidateAsPrimaryKey(desc, idx)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateVirtualColumnIndexing(t *testing.T) {
+	cols := makeTestColumns("a", "b", "v")
+	expr := "a + b"
+	cols[2].ComputeExpr = &expr
+	cols[2].Virtual = true
+	pkey := makeTestIndex(1, "t_pkey", 1)
+	pkey.StoreColumnIDs = []descpb.ColumnID{2}
+	valid := []descpb.IndexDescriptor{
+		makeTestIndex(2, "t_v", 3),
+		makeTestIndex(3, "t_b", 2),
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: pkey,
+		Indexes:      valid,
+	})
+	require.NoError(t, catalog.ValidateVirtualColumnIndexing(desc))
+
+	storingVirtual := makeTestIndex(4, "t_b_storing_v", 2)
+	storingVirtual.StoreColumnIDs = []descpb.ColumnID{3}
+	desc = buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: pkey,
+		Indexes:      append(valid, storingVirtual),
+	})
+	require.EqualError(t, catalog.ValidateVirtualColumnIndexing(desc),
+		`index "t_b_storing_v" cannot store virtual column "v"`)
+}
+
+func TestValidateUniqueColumnNames(t *testing.T) {
+	mutationColumn := func(
+		id descpb.ColumnID, name string, dir descpb.DescriptorMutation_Direction,
+	) descpb.DescriptorMutation {
+		return descpb.DescriptorMutation{
+			Descriptor_: &descpb.DescriptorMutation_Column{
+				Column: &descpb.ColumnDescriptor{ID: id, Name: name, Type: types.Int, Nullable: true},
+			},
+			State:     descpb.DescriptorMutation_DELETE_ONLY,
+			Direction: dir,
+		}
+	}
+	for _, tc := range []struct {
+		name      string
+		columns   []descpb.ColumnDescriptor
+		mutations []descpb.DescriptorMutation
+		err       string
+	}{
+		{
+			name:    "unique",
+			columns: makeTestColumns("a", "b", "c"),
+			mutations: []descpb.DescriptorMutation{
+				mutationColumn(4, "d", descpb.DescriptorMutation_ADD),
+			},
+		},
+		{
+			name: "public duplicate",
+			columns: func() []descpb.ColumnDescriptor {
+				cols := makeTestColumns("a", "b", "c")
+				cols[2].Name = "b"
+				return cols
+			}(),
+			err: `duplicate column name: "b"`,
+		},
+		{
+			name:    "mutation duplicate",
+			columns: makeTestColumns("a", "b", "c"),
+			mutations: []descpb.DescriptorMutation{
+				mutationColumn(4, "c", descpb.DescriptorMutation_ADD),
+			},
+			err: `duplicate column name: "c"`,
+		},
+		{
+			name:    "replacing dropped column",
+			columns: makeTestColumns("a", "b"),
+			mutations: []descpb.DescriptorMutation{
+				mutationColumn(3, "c", descpb.DescriptorMutation_DROP),
+				mutationColumn(4, "c", descpb.DescriptorMutation_ADD),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := buildTestTable(descpb.TableDescriptor{
+				Columns:      tc.columns,
+				PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+				Mutations:    tc.mutations,
+			})
+			err := catalog.ValidateUniqueColumnNames(desc)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestForEachComputedColumn(t *testing.T) {
+	cols := makeTestColumns("a", "b", "stored", "virtual", "c")
+	storedExpr, virtualExpr := "a + b", "a * b"
+	cols[2].ComputeExpr = &storedExpr
+	cols[3].ComputeExpr = &virtualExpr
+	cols[3].Virtual = true
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	})
+
+	var names []string
+	require.NoError(t, catalog.ForEachComputedColumn(desc, func(col catalog.Column) error {
+		names = append(names, col.GetName())
+		return nil
+	}))
+	require.Equal(t, []string{"stored", "virtual"}, names)
+
+	// Check that iteration can be stopped early.
+	names = names[:0]
+	require.NoError(t, catalog.ForEachComputedColumn(desc, func(col catalog.Column) error {
+		names = append(names, col.GetName())
+		return iterutil.StopIteration()
+	}))
+	require.Equal(t, []string{"stored"}, names)
+}
+
+func TestForEachInvertedIndex(t *testing.T) {
+	cols := makeTestColumns("a", "j", "g")
+	cols[1].Type = types.Jsonb
+	cols[2].Type = types.Geometry
+	invertedJ := makeTestIndex(2, "t_j_inverted", 2)
+	invertedJ.Type = descpb.IndexDescriptor_INVERTED
+	invertedAJ := makeTestIndex(3, "t_a_j_inverted", 1, 2)
+	invertedAJ.Type = descpb.IndexDescriptor_INVERTED
+	invertedG := makeTestIndex(4, "t_g_inverted", 3)
+	invertedG.Type = descpb.IndexDescriptor_INVERTED
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			invertedJ,
+			invertedAJ,
+			invertedG,
+			makeTestIndex(5, "t_a", 1),
+		},
+	})
+
+	var names []string
+	require.NoError(t, catalog.ForEachInvertedIndex(desc, func(idx catalog.Index) error {
+		names = append(names, idx.GetName())
+		return nil
+	}))
+	require.Equal(t, []string{"t_j_inverted", "t_a_j_inverted", "t_g_inverted"}, names)
+
+	// Check that iteration can be stopped early.
+	names = names[:0]
+	require.NoError(t, catalog.ForEachInvertedIndex(desc, func(idx catalog.Index) error {
+		names = append(names, idx.GetName())
+		return iterutil.StopIteration()
+	}))
+	require.Equal(t, []string{"t_j_inverted"}, names)
+
+	require.Equal(t, "t_j_inverted", catalog.FindInvertedIndexOnColumn(desc, 2).GetName())
+	require.Equal(t, "t_g_inverted", catalog.FindInvertedIndexOnColumn(desc, 3).GetName())
+	require.Nil(t, catalog.FindInvertedIndexOnColumn(desc, 1))
+}
+
+func TestIndexToCanonicalJSON(t *testing.T) {
+	withStored := func(idx descpb.IndexDescriptor, stored ...descpb.ColumnID) descpb.IndexDescriptor {
+		idx.StoreColumnIDs = stored
+		if idx.ID != 1 {
+			idx.KeySuffixColumnIDs = []descpb.ColumnID{1}
+		}
+		return idx
+	}
+	pk := withStored(makeTestIndex(1, "t_pkey", 1), 2, 3, 4)
+	pk.Unique = true
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: pk,
+		Indexes: []descpb.IndexDescriptor{
+			withStored(makeTestIndex(2, "t_b", 2), 3, 4),
+			withStored(makeTestIndex(3, "t_b_copy", 2), 4, 3),
+			withStored(makeTestIndex(4, "t_b_c", 2), 3),
+		},
+	})
+	canonicalJSON := func(name string) string {
+		idx, err := catalog.MustFindIndexByName(desc, name)
+		require.NoError(t, err)
+		ret, err := catalog.IndexToCanonicalJSON(idx)
+		require.NoError(t, err)
+		return string(ret)
+	}
+
+	require.Equal(t, `{"type":"FORWARD","unique":false,"key_column_ids":[2],`+
+		`"key_column_directions":["ASC"],"key_suffix_column_ids":[1],`+
+		`"stored_column_ids":[3,4],"composite_column_ids":[],"predicate":"",`+
+		`"sharded":{"is_sharded":false,"shard_buckets":0,"column_ids":[]},`+
+		`"geo_config":{},"invisibility":0}`,
+		canonicalJSON("t_b"))
+	require.Equal(t, canonicalJSON("t_b"), canonicalJSON("t_b_copy"))
+	require.NotEqual(t, canonicalJSON("t_b"), canonicalJSON("t_b_c"))
+	require.Equal(t, `{"type":"FORWARD","unique":true,"key_column_ids":[1],`+
+		`"key_column_directions":["ASC"],"key_suffix_column_ids":[],`+
+		`"stored_column_ids":[2,3,4],"composite_column_ids":[],"predicate":"",`+
+		`"sharded":{"is_sharded":false,"shard_buckets":0,"column_ids":[]},`+
+		`"geo_config":{},"invisibility":0}`,
+		canonicalJSON("t_pkey"))
+
+	// Properties which don't show up in the index's columns must still tell
+	// otherwise identical indexes apart.
+	t.Run("non-column properties", func(t *testing.T) {
+		base := withStored(makeTestIndex(2, "t_b", 2))
+		sharded := withStored(makeTestIndex(3, "t_b_sharded", 2))
+		sharded.Sharded = catpb.ShardedDescriptor{
+			IsSharded:    true,
+			Name:         "crdb_internal_b_shard_8",
+			ShardBuckets: 8,
+			ColumnNames:  []string{"b"},
+		}
+		invisible := withStored(makeTestIndex(4, "t_b_invisible", 2))
+		invisible.NotVisible = true
+		invisible.Invisibility = 1.0
+		inverted := withStored(makeTestIndex(5, "t_b_inverted", 2))
+		inverted.Type = descpb.IndexDescriptor_INVERTED
+		trigram := inverted
+		trigram.ID, trigram.Name = 6, "t_b_trigram"
+		trigram.InvertedColumnKinds = []catpb.InvertedIndexColumnKind{catpb.InvertedIndexColumnKind_TRIGRAM}
+		geo := inverted
+		geo.ID, geo.Name = 7, "t_b_geo"
+		geo.GeoConfig = geopb.Config{S2Geometry: &geopb.S2GeometryConfig{
+			MinX: -1, MaxX: 1, MinY: -1, MaxY: 1,
+		}}
+		desc := buildTestTable(descpb.TableDescriptor{
+			Columns:      makeTestColumns("a", "b", "c", "d"),
+			PrimaryIndex: pk,
+			Indexes:      []descpb.IndexDescriptor{base, sharded, invisible, inverted, trigram, geo},
+		})
+		seen := make(map[string]string)
+		for _, idx := range desc.PublicNonPrimaryIndexes() {
+			ret, err := catalog.IndexToCanonicalJSON(idx)
+			require.NoError(t, err)
+			if other, ok := seen[string(ret)]; ok {
+				t.Errorf("%s and %s have the same canonical JSON %s", other, idx.GetName(), ret)
+			}
+			seen[string(ret)] = idx.GetName()
+		}
+	})
+
+	// Renaming the column a hash sharded index is computed over rewrites the
+	// names in its sharded descriptor, but not its canonical JSON.
+	t.Run("sharded column rename", func(t *testing.T) {
+		before := makeShardedTestTable()
+		after := renameTestColumn(t, before, "b", "b_renamed")
+		idx, err := catalog.MustFindIndexByName(after, "t_b_shard")
+		require.NoError(t, err)
+		require.Equal(t, []string{"b_renamed"}, idx.GetSharded().ColumnNames)
+
+		canonicalJSON := func(desc catalog.TableDescriptor) string {
+			idx, err := catalog.MustFindIndexByName(desc, "t_b_shard")
+			require.NoError(t, err)
+			ret, err := catalog.IndexToCanonicalJSON(idx)
+			require.NoError(t, err)
+			return string(ret)
+		}
+		require.Contains(t, canonicalJSON(before),
+			`"sharded":{"is_sharded":true,"shard_buckets":4,"column_ids":[2]}`)
+		require.Equal(t, canonicalJSON(before), canonicalJSON(after))
+	})
+}
+
+func TestSchemaFingerprint(t *testing.T) {
+	makeDesc := func(name string, mutate func(desc *descpb.TableDescriptor)) descpb.TableDescriptor {
+		idx := makeTestIndex(2, name+"_b", 2)
+		idx.KeySuffixColumnIDs = []descpb.ColumnID{1}
+		desc := descpb.TableDescriptor{
+			Name:         name,
+			Columns:      makeTestColumns("a", "b", "c"),
+			PrimaryIndex: makeTestIndex(1, name+"_pkey", 1),
+			Indexes:      []descpb.IndexDescriptor{idx},
+		}
+		desc.PrimaryIndex.Unique = true
+		desc.PrimaryIndex.StoreColumnIDs = []descpb.ColumnID{2, 3}
+		if mutate != nil {
+			mutate(&desc)
+		}
+		return desc
+	}
+	fingerprint := func(desc descpb.TableDescriptor) uint64 {
+		fp, err := catalog.SchemaFingerprint(buildTestTable(desc))
+		require.NoError(t, err)
+		return fp
+	}
+
+	base := fingerprint(makeDesc("t", nil))
+	// Names and modification times are ignored.
+	require.Equal(t, base, fingerprint(makeDesc("u", func(desc *descpb.TableDescriptor) {
+		desc.ID = 101
+		desc.Columns[2].Name = "z"
+		desc.ModificationTime.WallTime = 123
+	})))
+	// That includes renaming the column a hash sharded index is computed over,
+	// which also renames the shard column and rewrites the sharded descriptor.
+	sharded := makeShardedTestTable()
+	shardedFP, err := catalog.SchemaFingerprint(sharded)
+	require.NoError(t, err)
+	renamedFP, err := catalog.SchemaFingerprint(renameTestColumn(t, sharded, "b", "b_renamed"))
+	require.NoError(t, err)
+	require.Equal(t, shardedFP, renamedFP)
+	// Structural changes are not.
+	for name, mutate := range map[string]func(desc *descpb.TableDescriptor){
+		"type": func(desc *descpb.TableDescriptor) {
+			desc.Columns[2].Type = types.String
+		},
+		"width": func(desc *descpb.TableDescriptor) {
+			desc.Columns[2].Type = types.Int4
+		},
+		"nullability": func(desc *descpb.TableDescriptor) {
+			desc.Columns[2].Nullable = false
+		},
+		"index key": func(desc *descpb.TableDescriptor) {
+			desc.Indexes[0].KeyColumnIDs = []descpb.ColumnID{3}
+		},
+		"index direction": func(desc *descpb.TableDescriptor) {
+			desc.Indexes[0].KeyColumnDirections[0] = catenumpb.IndexColumn_DESC
+		},
+		"index ID": func(desc *descpb.TableDescriptor) {
+			desc.Indexes[0].ID = 3
+		},
+	} {
+		require.NotEqual(t, base, fingerprint(makeDesc("t", mutate)), name)
+	}
+}
+
+func TestColumnsWithDefaults(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d", "e")
+	defaultExpr, onUpdateExpr := "1:::INT8", "2:::INT8"
+	cols[1].DefaultExpr = &defaultExpr
+	cols[2].OnUpdateExpr = &onUpdateExpr
+	cols[3].GeneratedAsIdentityType = catpb.GeneratedAsIdentityType_GENERATED_ALWAYS
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	})
+
+	var names []string
+	for _, col := range catalog.ColumnsWithDefaults(desc) {
+		names = append(names, col.GetName())
+	}
+	require.Equal(t, []string{"b", "c", "d"}, names)
+}
+
+func TestColumnsHaveIdenticalType(t *testing.T) {
+	cols := makeTestColumns("s", "s_en", "s_en2", "s_fr", "v10", "v20", "e", "e_other")
+	cols[0].Type = types.String
+	cols[1].Type = types.MakeCollatedString(types.String, "en")
+	cols[2].Type = types.MakeCollatedString(types.String, "en")
+	cols[3].Type = types.MakeCollatedString(types.String, "fr")
+	cols[4].Type = types.MakeVarChar(10)
+	cols[5].Type = types.MakeVarChar(20)
+	cols[6].Type = types.MakeEnum(100100, 100101)
+	cols[7].Type = types.MakeEnum(100200, 100201)
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	})
+	mustFind := func(name string) catalog.Column {
+		col, err := catalog.MustFindColumnByName(desc, name)
+		require.NoError(t, err)
+		return col
+	}
+
+	for _, tc := range []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "s", b: "s", expected: true},
+		{a: "s_en", b: "s_en2", expected: true},
+		{a: "s", b: "s_en", expected: false},
+		{a: "s_en", b: "s_fr", expected: false},
+		{a: "v10", b: "v10", expected: true},
+		{a: "v10", b: "v20", expected: false},
+		{a: "s", b: "v10", expected: false},
+		{a: "e", b: "e", expected: true},
+		{a: "e", b: "e_other", expected: false},
+	} {
+		t.Run(fmt.Sprintf("%s/%s", tc.a, tc.b), func(t *testing.T) {
+			require.Equal(t, tc.expected, catalog.ColumnsHaveIdenticalType(mustFind(tc.a), mustFind(tc.b)))
+			require.Equal(t, tc.expected, catalog.ColumnsHaveIdenticalType(mustFind(tc.b), mustFind(tc.a)))
+		})
+	}
+
+	// The same enum type hydrated from different versions of its descriptor.
+	hydrated := func(version uint32) catalog.Column {
+		col := mustFind("e").DeepCopy()
+		typ := *col.GetType()
+		typ.TypeMeta.Version = version
+		col.ColumnDesc().Type = &typ
+		return col
+	}
+	require.True(t, catalog.ColumnsHaveIdenticalType(hydrated(2), hydrated(2)))
+	require.False(t, catalog.ColumnsHaveIdenticalType(hydrated(1), hydrated(2)))
+}
+
+func TestPrimaryKeyColumnOrdinals(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		keyCols  []descpb.ColumnID
+		expected []int
+	}{
+		{name: "single", keyCols: []descpb.ColumnID{1}, expected: []int{0}},
+		{name: "composite", keyCols: []descpb.ColumnID{3, 1, 4}, expected: []int{2, 0, 3}},
+		{name: "unknown", keyCols: []descpb.ColumnID{2, 9}, expected: []int{1, -1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := buildTestTable(descpb.TableDescriptor{
+				Columns:      makeTestColumns("a", "b", "c", "d"),
+				PrimaryIndex: makeTestIndex(1, "t_pkey", tc.keyCols...),
+			})
+			require.Equal(t, tc.expected, catalog.PrimaryKeyColumnOrdinals(desc))
+		})
+	}
+}
+
+func TestNotNullColumnIDs(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d")
+	cols[2].Nullable = false
+	// Column a is a primary key column which lacks an explicit NOT NULL flag.
+	require.True(t, cols[0].Nullable)
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes:      []descpb.IndexDescriptor{makeTestIndex(2, "t_b", 2)},
+	})
+	require.Equal(t, catalog.MakeTableColSet(1, 3), catalog.NotNullColumnIDs(desc))
+}
+
+func TestForEachUniqueConstraint(t *testing.T) {
+	unique := makeTestIndex(2, "t_b_c_key", 2, 3)
+	unique.Unique = true
+	dropping := descpb.UniqueWithoutIndexConstraint{
+		TableID:      100,
+		Name:         "unique_d_b_dropping",
+		ColumnIDs:    []descpb.ColumnID{4, 2},
+		Validity:     descpb.ConstraintValidity_Dropping,
+		ConstraintID: 5,
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			unique,
+			makeTestIndex(3, "t_d", 4),
+		},
+		UniqueWithoutIndexConstraints: []descpb.UniqueWithoutIndexConstraint{
+			{
+				TableID:      100,
+				Name:         "unique_d",
+				ColumnIDs:    []descpb.ColumnID{4},
+				Validity:     descpb.ConstraintValidity_Validated,
+				ConstraintID: 4,
+			},
+			dropping,
+		},
+		Mutations: []descpb.DescriptorMutation{
+			{
+				Descriptor_: &descpb.DescriptorMutation_Constraint{
+					Constraint: &descpb.ConstraintToUpdate{
+						ConstraintType:               descpb.ConstraintToUpdate_UNIQUE_WITHOUT_INDEX,
+						Name:                         dropping.Name,
+						UniqueWithoutIndexConstraint: dropping,
+					},
+				},
+				State:     descpb.DescriptorMutation_WRITE_ONLY,
+				Direction: descpb.DescriptorMutation_DROP,
+			},
+		},
+	})
+
+	type uniqueConstraint struct {
+		name        string
+		colIDs      descpb.ColumnIDs
+		indexBacked bool
+	}
+	var actual []uniqueConstraint
+	require.NoError(t, catalog.ForEachUniqueConstraint(desc, func(
+		name string, colIDs descpb.ColumnIDs, indexBacked bool,
+	) error {
+		actual = append(actual, uniqueConstraint{name: name, colIDs: colIDs, indexBacked: indexBacked})
+		return nil
+	}))
+	require.Equal(t, []uniqueConstraint{
+		{name: "t_pkey", colIDs: descpb.ColumnIDs{1}, indexBacked: true},
+		{name: "t_b_c_key", colIDs: descpb.ColumnIDs{2, 3}, indexBacked: true},
+		{name: "unique_d", colIDs: descpb.ColumnIDs{4}, indexBacked: false},
+	}, actual)
+
+	// Check that iteration can be stopped early.
+	var names []string
+	require.NoError(t, catalog.ForEachUniqueConstraint(desc, func(
+		name string, _ descpb.ColumnIDs, _ bool,
+	) error {
+		names = append(names, name)
+		if len(names) == 2 {
+			return iterutil.StopIteration()
+		}
+		return nil
+	}))
+	require.Equal(t, []string{"t_pkey", "t_b_c_key"}, names)
+}
+
+func TestFindIndexForUniqueConstraintName(t *testing.T) {
+	unique := makeTestIndex(2, "t_b_c_key", 2, 3)
+	unique.Unique = true
+	droppingUnique := makeTestIndex(4, "t_c_key", 3)
+	droppingUnique.Unique = true
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			unique,
+			makeTestIndex(3, "t_d", 4),
+		},
+		UniqueWithoutIndexConstraints: []descpb.UniqueWithoutIndexConstraint{{
+			TableID:      100,
+			Name:         "unique_d",
+			ColumnIDs:    []descpb.ColumnID{4},
+			Validity:     descpb.ConstraintValidity_Validated,
+			ConstraintID: 4,
+		}},
+		Mutations: []descpb.DescriptorMutation{{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &droppingUnique},
+			State:       descpb.DescriptorMutation_DELETE_ONLY,
+			Direction:   descpb.DescriptorMutation_DROP,
+			MutationID:  1,
+		}},
+	})
+
+	for _, tc := range []struct {
+		name    string
+		indexID descpb.IndexID
+		ok      bool
+	}{
+		{name: "t_pkey", indexID: 1, ok: true},
+		{name: "t_b_c_key", indexID: 2, ok: true},
+		// Not a unique index.
+		{name: "t_d", ok: false},
+		// Not backed by an index.
+		{name: "unique_d", ok: false},
+		// Being dropped.
+		{name: "t_c_key", ok: false},
+		{name: "unknown", ok: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, ok := catalog.FindIndexForUniqueConstraintName(desc, tc.name)
+			require.Equal(t, tc.ok, ok)
+			if !tc.ok {
+				require.Nil(t, idx)
+				return
+			}
+			require.Equal(t, tc.indexID, idx.GetID())
+			require.Equal(t, tc.name, idx.GetName())
+		})
+	}
+}
+
+func TestForEachCheckConstraint(t *testing.T) {
+	adding := descpb.TableDescriptor_CheckConstraint{
+		Name:         "check_c",
+		Expr:         "c > 0:::INT8",
+		ColumnIDs:    []descpb.ColumnID{3},
+		Validity:     descpb.ConstraintValidity_Validating,
+		ConstraintID: 4,
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Checks: []*descpb.TableDescriptor_CheckConstraint{
+			{
+				Name:         "check_a",
+				Expr:         "a > 0:::INT8",
+				ColumnIDs:    []descpb.ColumnID{1},
+				Validity:     descpb.ConstraintValidity_Validated,
+				ConstraintID: 2,
+			},
+			{
+				Name:         "check_b",
+				Expr:         "b > 0:::INT8",
+				ColumnIDs:    []descpb.ColumnID{2},
+				Validity:     descpb.ConstraintValidity_Unvalidated,
+				ConstraintID: 3,
+			},
+		},
+		Mutations: []descpb.DescriptorMutation{
+			{
+				Descriptor_: &descpb.DescriptorMutation_Constraint{
+					Constraint: &descpb.ConstraintToUpdate{
+						ConstraintType: descpb.ConstraintToUpdate_CHECK,
+						Name:           adding.Name,
+						Check:          adding,
+					},
+				},
+				State:     descpb.DescriptorMutation_DELETE_ONLY,
+				Direction: descpb.DescriptorMutation_ADD,
+			},
+		},
+	})
+
+	type check struct {
+		name     string
+		validity descpb.ConstraintValidity
+	}
+	var actual []check
+	require.NoError(t, catalog.ForEachCheckConstraint(desc, func(
+		ck *descpb.TableDescriptor_CheckConstraint,
+	) error {
+		actual = append(actual, check{name: ck.Name, validity: ck.Validity})
+		return nil
+	}))
+	require.Equal(t, []check{
+		{name: "check_a", validity: descpb.ConstraintValidity_Validated},
+		{name: "check_b", validity: descpb.ConstraintValidity_Unvalidated},
+		{name: "check_c", validity: descpb.ConstraintValidity_Validating},
+	}, actual)
+
+	// Check that iteration can be stopped early.
+	var names []string
+	require.NoError(t, catalog.ForEachCheckConstraint(desc, func(
+		ck *descpb.TableDescriptor_CheckConstraint,
+	) error {
+		names = append(names, ck.Name)
+		return iterutil.StopIteration()
+	}))
+	require.Equal(t, []string{"check_a"}, names)
+}
+
+func TestColumnsInCheckConstraints(t *testing.T) {
+	dropping := descpb.TableDescriptor_CheckConstraint{
+		Name:         "check_d_e",
+		Expr:         "d < e",
+		ColumnIDs:    []descpb.ColumnID{4, 5},
+		Validity:     descpb.ConstraintValidity_Dropping,
+		ConstraintID: 4,
+	}
+	makeDesc := func(checks ...*descpb.TableDescriptor_CheckConstraint) catalog.TableDescriptor {
+		return buildTestTable(descpb.TableDescriptor{
+			Columns:      makeTestColumns("a", "b", "c", "d", "e", "f"),
+			PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+			Checks:       checks,
+			Mutations: []descpb.DescriptorMutation{{
+				Descriptor_: &descpb.DescriptorMutation_Constraint{
+					Constraint: &descpb.ConstraintToUpdate{
+						ConstraintType: descpb.ConstraintToUpdate_CHECK,
+						Name:           dropping.Name,
+						Check:          dropping,
+					},
+				},
+				State:     descpb.DescriptorMutation_WRITE_ONLY,
+				Direction: descpb.DescriptorMutation_DROP,
+			}},
+		})
+	}
+
+	cols, err := catalog.ColumnsInCheckConstraints(makeDesc(
+		&descpb.TableDescriptor_CheckConstraint{
+			Name:         "check_a_b",
+			Expr:         "a + b > 0:::INT8",
+			ColumnIDs:    []descpb.ColumnID{1, 2},
+			ConstraintID: 2,
+		},
+		&descpb.TableDescriptor_CheckConstraint{
+			Name:         "check_b_c",
+			Expr:         "b != c",
+			ColumnIDs:    []descpb.ColumnID{2, 3},
+			ConstraintID: 3,
+		},
+	))
+	require.NoError(t, err)
+	require.Equal(t, "{1,2,3,4,5}", cols.String())
+
+	_, err = catalog.ColumnsInCheckConstraints(makeDesc(
+		&descpb.TableDescriptor_CheckConstraint{
+			Name:         "check_unknown",
+			Expr:         "a > 0:::INT8",
+			ColumnIDs:    []descpb.ColumnID{1, 7},
+			ConstraintID: 2,
+		},
+	))
+	require.ErrorContains(t, err, `check constraint "check_unknown" of table "t" references unknown column 7`)
+}
+
+func TestForEachForeignKeyReferencingColumn(t *testing.T) {
+	uniqueB := makeTestIndex(2, "t_b_key", 2)
+	uniqueB.Unique = true
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes:      []descpb.IndexDescriptor{uniqueB},
+		OutboundFKs: []descpb.ForeignKeyConstraint{
+			{
+				Name:                "fk_b_out",
+				OriginTableID:       100,
+				OriginColumnIDs:     []descpb.ColumnID{2},
+				ReferencedTableID:   200,
+				ReferencedColumnIDs: []descpb.ColumnID{1},
+				ConstraintID:        3,
+			},
+			{
+				Name:                "fk_c_d_out",
+				OriginTableID:       100,
+				OriginColumnIDs:     []descpb.ColumnID{3, 4},
+				ReferencedTableID:   200,
+				ReferencedColumnIDs: []descpb.ColumnID{2, 3},
+				ConstraintID:        4,
+			},
+		},
+		InboundFKs: []descpb.ForeignKeyConstraint{
+			{
+				Name:                "fk_b_in",
+				OriginTableID:       300,
+				OriginColumnIDs:     []descpb.ColumnID{1},
+				ReferencedTableID:   100,
+				ReferencedColumnIDs: []descpb.ColumnID{2},
+			},
+		},
+	})
+
+	type fk struct {
+		name    string
+		inbound bool
+	}
+	collect := func(colID descpb.ColumnID) (actual []fk) {
+		require.NoError(t, catalog.ForEachForeignKeyReferencingColumn(desc, colID, func(
+			fkDesc *descpb.ForeignKeyConstraint, inbound bool,
+		) error {
+			actual = append(actual, fk{name: fkDesc.Name, inbound: inbound})
+			return nil
+		}))
+		return actual
+	}
+	require.Empty(t, collect(1))
+	require.Equal(t, []fk{{name: "fk_b_out"}, {name: "fk_b_in", inbound: true}}, collect(2))
+	require.Equal(t, []fk{{name: "fk_c_d_out"}}, collect(3))
+	require.Equal(t, []fk{{name: "fk_c_d_out"}}, collect(4))
+
+	// Check that iteration can be stopped early.
+	var names []string
+	require.NoError(t, catalog.ForEachForeignKeyReferencingColumn(desc, 2, func(
+		fkDesc *descpb.ForeignKeyConstraint, inbound bool,
+	) error {
+		names = append(names, fkDesc.Name)
+		return iterutil.StopIteration()
+	}))
+	require.Equal(t, []string{"fk_b_out"}, names)
+}
+
+func TestConstrainedKeyPrefixLength(t *testing.T) {
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes:      []descpb.IndexDescriptor{makeTestIndex(2, "t_b_c_d", 2, 3, 4)},
+	})
+	idx, err := catalog.MustFindIndexByName(desc, "t_b_c_d")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		equalityCols catalog.TableColSet
+		expected     int
+	}{
+		{equalityCols: catalog.TableColSet{}, expected: 0},
+		{equalityCols: catalog.MakeTableColSet(1), expected: 0},
+		{equalityCols: catalog.MakeTableColSet(2), expected: 1},
+		{equalityCols: catalog.MakeTableColSet(2, 3), expected: 2},
+		{equalityCols: catalog.MakeTableColSet(1, 2, 3, 4), expected: 3},
+		// Gaps in the equality set end the constrained prefix.
+		{equalityCols: catalog.MakeTableColSet(2, 4), expected: 1},
+		{equalityCols: catalog.MakeTableColSet(3, 4), expected: 0},
+	} {
+		t.Run(tc.equalityCols.String(), func(t *testing.T) {
+			require.Equal(t, tc.expected, catalog.ConstrainedKeyPrefixLength(idx, tc.equalityCols))
+		})
+	}
+}
+
+func TestIndexProvidesOrdering(t *testing.T) {
+	mixed := makeTestIndex(2, "t_b_c_d", 2, 3, 4)
+	mixed.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes:      []descpb.IndexDescriptor{mixed},
+	})
+	idx, err := catalog.MustFindIndexByName(desc, "t_b_c_d")
+	require.NoError(t, err)
+
+	const asc, dsc = catenumpb.IndexColumn_ASC, catenumpb.IndexColumn_DESC
+	for _, tc := range []struct {
+		name     string
+		cols     []descpb.ColumnID
+		dirs     []catenumpb.IndexColumn_Direction
+		expected bool
+	}{
+		{name: "empty", expected: true},
+		{
+			name:     "forward prefix",
+			cols:     []descpb.ColumnID{2, 3},
+			dirs:     []catenumpb.IndexColumn_Direction{asc, dsc},
+			expected: true,
+		},
+		{
+			name:     "forward full",
+			cols:     []descpb.ColumnID{2, 3, 4},
+			dirs:     []catenumpb.IndexColumn_Direction{asc, dsc, asc},
+			expected: true,
+		},
+		{
+			name:     "reverse",
+			cols:     []descpb.ColumnID{2, 3, 4},
+			dirs:     []catenumpb.IndexColumn_Direction{dsc, asc, dsc},
+			expected: true,
+		},
+		{
+			name:     "mixed directions",
+			cols:     []descpb.ColumnID{2, 3},
+			dirs:     []catenumpb.IndexColumn_Direction{asc, asc},
+			expected: false,
+		},
+		{
+			name:     "not a prefix",
+			cols:     []descpb.ColumnID{3, 4},
+			dirs:     []catenumpb.IndexColumn_Direction{dsc, asc},
+			expected: false,
+		},
+		{
+			name:     "wrong column order",
+			cols:     []descpb.ColumnID{2, 4},
+			dirs:     []catenumpb.IndexColumn_Direction{asc, asc},
+			expected: false,
+		},
+		{
+			name:     "too many columns",
+			cols:     []descpb.ColumnID{2, 3, 4, 1},
+			dirs:     []catenumpb.IndexColumn_Direction{asc, dsc, asc, asc},
+			expected: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, catalog.IndexProvidesOrdering(idx, tc.cols, tc.dirs))
+		})
+	}
+}
+
+func TestHasSelfReferentialForeignKey(t *testing.T) {
+	uniqueB := makeTestIndex(2, "t_b_key", 2)
+	uniqueB.Unique = true
+	fkToOther := descpb.ForeignKeyConstraint{
+		Name:                "fk_other",
+		OriginTableID:       100,
+		OriginColumnIDs:     []descpb.ColumnID{2},
+		ReferencedTableID:   200,
+		ReferencedColumnIDs: []descpb.ColumnID{1},
+		ConstraintID:        2,
+	}
+	fkToSelf := descpb.ForeignKeyConstraint{
+		Name:                "fk_self",
+		OriginTableID:       100,
+		OriginColumnIDs:     []descpb.ColumnID{3},
+		ReferencedTableID:   100,
+		ReferencedColumnIDs: []descpb.ColumnID{2},
+		ConstraintID:        3,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fks      []descpb.ForeignKeyConstraint
+		expected bool
+	}{
+		{name: "none"},
+		{name: "other", fks: []descpb.ForeignKeyConstraint{fkToOther}},
+		{name: "self", fks: []descpb.ForeignKeyConstraint{fkToSelf}, expected: true},
+		{name: "both", fks: []descpb.ForeignKeyConstraint{fkToOther, fkToSelf}, expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := buildTestTable(descpb.TableDescriptor{
+				Columns:      makeTestColumns("a", "b", "c"),
+				PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+				Indexes:      []descpb.IndexDescriptor{uniqueB},
+				OutboundFKs:  tc.fks,
+			})
+			require.Equal(t, tc.expected, catalog.HasSelfReferentialForeignKey(desc))
+		})
+	}
+}
+
+func TestCommonKeyPrefixLength(t *testing.T) {
+	descending := makeTestIndex(6, "t_b_c_desc", 2, 3)
+	descending.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			makeTestIndex(2, "t_b_c_d", 2, 3, 4),
+			makeTestIndex(3, "t_b_c_d_copy", 2, 3, 4),
+			makeTestIndex(4, "t_b_c", 2, 3),
+			makeTestIndex(5, "t_b_d", 2, 4),
+			descending,
+		},
+	})
+	mustFindIndex := func(name string) catalog.Index {
+		idx, err := catalog.MustFindIndexByName(desc, name)
+		require.NoError(t, err)
+		return idx
+	}
+
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		// Fully matching.
+		{"t_b_c_d", "t_b_c_d_copy", 3},
+		{"t_b_c_d", "t_b_c", 2},
+		{"t_b_c", "t_b_c_d", 2},
+		// Partially matching.
+		{"t_b_c_d", "t_b_d", 1},
+		// Mismatched directions.
+		{"t_b_c", "t_b_c_desc", 1},
+		// Nothing in common.
+		{"t_b_c_d", "t_pkey", 0},
+	} {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			require.Equal(t, tc.expected, catalog.CommonKeyPrefixLength(mustFindIndex(tc.a), mustFindIndex(tc.b)))
+		})
+	}
+}
+
+func TestPrimaryKeyHasComputedColumns(t *testing.T) {
+	cols := makeTestColumns("a", "b", "crdb_internal_a_shard_4")
+	cols[0].Nullable = false
+	shardExpr := "mod(fnv32(md5(crdb_internal.datums_to_bytes(a))), 4:::INT8)"
+	cols[2].ComputeExpr = &shardExpr
+	cols[2].Virtual = true
+	cols[2].Hidden = true
+	cols[2].Nullable = false
+	shardedPK := makeTestIndex(1, "t_pkey", 3, 1)
+	shardedPK.Sharded = catpb.ShardedDescriptor{
+		IsSharded:    true,
+		Name:         "crdb_internal_a_shard_4",
+		ShardBuckets: 4,
+		ColumnNames:  []string{"a"},
+	}
+
+	plain := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	})
+	require.False(t, catalog.PrimaryKeyHasComputedColumns(plain))
+
+	sharded := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: shardedPK,
+	})
+	require.True(t, catalog.PrimaryKeyHasComputedColumns(sharded))
+}
+
+func TestIndexBefore(t *testing.T) {
+	idx := makeTestIndex(5, "t_b_adding", 2)
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			makeTestIndex(2, "t_b", 2),
+			makeTestIndex(3, "t_c", 3),
+			makeTestIndex(4, "t_b_c", 2, 3),
+		},
+		Mutations: []descpb.DescriptorMutation{{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &idx},
+			State:       descpb.DescriptorMutation_DELETE_ONLY,
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  1,
+		}},
+	})
+	canonical := desc.AllIndexes()
+	require.True(t, catalog.IndexBefore(canonical[0], canonical[1]))
+	require.False(t, catalog.IndexBefore(canonical[1], canonical[0]))
+	require.False(t, catalog.IndexBefore(canonical[1], canonical[1]))
+
+	rng, _ := randutil.NewTestRand()
+	shuffled := append([]catalog.Index(nil), canonical...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	sort.Slice(shuffled, func(i, j int) bool {
+		return catalog.IndexBefore(shuffled[i], shuffled[j])
+	})
+	require.Equal(t, canonical, shuffled)
+}
+
+func TestIndexKeyPrefixLength(t *testing.T) {
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			makeTestIndex(109, "t_b_109", 2),
+			makeTestIndex(110, "t_b_110", 2),
+			makeTestIndex(300, "t_b_300", 2),
+		},
+	})
+	tenantCodec := keys.MakeSQLCodec(roachpb.MustMakeTenantID(5))
+
+	// The table ID 100 is encoded in a single byte, as are index IDs up to 109,
+	// while larger index IDs are encoded as a tag byte followed by their
+	// big-endian value. Secondary tenant keys are additionally prefixed by a
+	// tenant prefix byte followed by the tenant ID.
+	for _, tc := range []struct {
+		name           string
+		system, tenant int
+	}{
+		{name: "t_pkey", system: 2, tenant: 4},
+		{name: "t_b_109", system: 2, tenant: 4},
+		{name: "t_b_110", system: 3, tenant: 5},
+		{name: "t_b_300", system: 4, tenant: 6},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.name)
+			require.NoError(t, err)
+			require.Equal(t, tc.system, catalog.IndexKeyPrefixLength(keys.SystemSQLCodec, desc, idx))
+			require.Equal(t, tc.tenant, catalog.IndexKeyPrefixLength(tenantCodec, desc, idx))
+		})
+	}
+}
+
+func TestIndexesSharingColumn(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d", "v")
+	virtualExpr := "a + b"
+	cols[4].ComputeExpr = &virtualExpr
+	cols[4].Virtual = true
+	pkey := makeTestIndex(1, "t_pkey", 1)
+	pkey.StoreColumnIDs = []descpb.ColumnID{2, 3, 4}
+	storingC := makeTestIndex(2, "t_b_storing_c", 2)
+	storingC.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	storingC.StoreColumnIDs = []descpb.ColumnID{3}
+	keyedC := makeTestIndex(3, "t_c", 3)
+	keyedC.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	keyedV := makeTestIndex(4, "t_v", 5)
+	keyedV.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: pkey,
+		Indexes:      []descpb.IndexDescriptor{storingC, keyedC, keyedV},
+	})
+
+	for _, tc := range []struct {
+		colID    descpb.ColumnID
+		expected []string
+	}{
+		{colID: 1, expected: []string{"t_pkey", "t_b_storing_c", "t_c", "t_v"}},
+		{colID: 2, expected: []string{"t_pkey", "t_b_storing_c"}},
+		// Stored in one secondary index, keyed in another.
+		{colID: 3, expected: []string{"t_pkey", "t_b_storing_c", "t_c"}},
+		{colID: 4, expected: []string{"t_pkey"}},
+		// Virtual columns are only in the indexes which they're a key column of.
+		{colID: 5, expected: []string{"t_v"}},
+		{colID: 6},
+	} {
+		t.Run(fmt.Sprintf("%d", tc.colID), func(t *testing.T) {
+			var names []string
+			for _, idx := range catalog.IndexesSharingColumn(desc, tc.colID) {
+				names = append(names, idx.GetName())
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestIndexesAffectedByColumnTypeChange(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d", "e")
+	pkey := makeTestIndex(1, "t_pkey", 1)
+	pkey.StoreColumnIDs = []descpb.ColumnID{2, 3, 4, 5}
+	keyedB := makeTestIndex(2, "t_b", 2)
+	keyedB.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	storingB := makeTestIndex(3, "t_c_storing_b", 3)
+	storingB.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	storingB.StoreColumnIDs = []descpb.ColumnID{2}
+	keyedD := makeTestIndex(4, "t_d", 4)
+	keyedD.KeySuffixColumnIDs = []descpb.ColumnID{1}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols,
+		PrimaryIndex: pkey,
+		Indexes:      []descpb.IndexDescriptor{keyedB, storingB, keyedD},
+	})
+
+	for _, tc := range []struct {
+		colID    descpb.ColumnID
+		expected []string
+	}{
+		// Primary key column, in every key suffix.
+		{colID: 1, expected: []string{"t_pkey", "t_b", "t_c_storing_b", "t_d"}},
+		// Keyed in one index, stored in another.
+		{colID: 2, expected: []string{"t_pkey", "t_b", "t_c_storing_b"}},
+		// Key-only dependence.
+		{colID: 3, expected: []string{"t_pkey", "t_c_storing_b"}},
+		{colID: 4, expected: []string{"t_pkey", "t_d"}},
+		// Stored-only dependence.
+		{colID: 5, expected: []string{"t_pkey"}},
+	} {
+		t.Run(fmt.Sprintf("%d", tc.colID), func(t *testing.T) {
+			var names []string
+			for _, idx := range catalog.IndexesAffectedByColumnTypeChange(desc, tc.colID) {
+				names = append(names, idx.GetName())
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestSecondaryIndexKeySuffixMap(t *testing.T) {
+	withSuffix := func(
+		idx descpb.IndexDescriptor, unique bool, suffix ...descpb.ColumnID,
+	) descpb.IndexDescriptor {
+		idx.Unique = unique
+		idx.KeySuffixColumnIDs = suffix
+		return idx
+	}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1, 2),
+		Indexes: []descpb.IndexDescriptor{
+			withSuffix(makeTestIndex(2, "t_c", 3), false /* unique */, 1, 2),
+			withSuffix(makeTestIndex(3, "t_d_key", 4), true /* unique */, 1, 2),
+			withSuffix(makeTestIndex(4, "t_b_c", 2, 3), false /* unique */, 1),
+			withSuffix(makeTestIndex(5, "t_b_a_key", 2, 1), true /* unique */),
+		},
+	})
+
+	require.Equal(t, map[descpb.IndexID]descpb.ColumnIDs{
+		2: {1, 2},
+		3: {1, 2},
+		4: {1},
+		5: {},
+	}, catalog.SecondaryIndexKeySuffixMap(desc))
+}
+
+func TestIndexesRequiringRewriteForNewPK(t *testing.T) {
+	withSuffix := func(idx descpb.IndexDescriptor, suffix ...descpb.ColumnID) descpb.IndexDescriptor {
+		idx.KeySuffixColumnIDs = suffix
+		return idx
+	}
+	// An index storing c in the old format, in which the stored columns are
+	// kept among the key suffix columns.
+	oldStoring := withSuffix(makeTestIndex(6, "t_d_old_storing", 4), 1, 3)
+	oldStoring.StoreColumnNames = []string{"c"}
+	// The new primary index of an in-progress primary key change, which has no
+	// key suffix.
+	newPrimary := makeTestIndex(7, "t_pkey_new", 3)
+	newPrimary.EncodingType = catenumpb.PrimaryIndexEncoding
+	newPrimary.StoreColumnIDs = []descpb.ColumnID{1, 2, 4}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Indexes: []descpb.IndexDescriptor{
+			withSuffix(makeTestIndex(2, "t_b", 2), 1),
+			withSuffix(makeTestIndex(3, "t_b_a", 2, 1)),
+			withSuffix(makeTestIndex(4, "t_c", 3), 1),
+			withSuffix(makeTestIndex(5, "t_d_b", 4, 2), 1),
+			oldStoring,
+		},
+		Mutations: []descpb.DescriptorMutation{{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &newPrimary},
+			State:       descpb.DescriptorMutation_WRITE_ONLY,
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  1,
+		}},
+	})
+	oldStoringIdx, err := catalog.MustFindIndexByName(desc, "t_d_old_storing")
+	require.NoError(t, err)
+	require.True(t, oldStoringIdx.HasOldStoredColumns())
+
+	for _, tc := range []struct {
+		newPK    descpb.ColumnIDs
+		expected []string
+	}{
+		// The current primary key requires no rewrites, including for the index
+		// with old-format stored columns, whose stored column c isn't part of its
+		// actual key suffix.
+		{newPK: descpb.ColumnIDs{1}, expected: nil},
+		// Indexes containing b already have the new key suffix.
+		{newPK: descpb.ColumnIDs{1, 2}, expected: []string{"t_c", "t_d_old_storing"}},
+		{newPK: descpb.ColumnIDs{2}, expected: []string{"t_b", "t_c", "t_d_b", "t_d_old_storing"}},
+		{newPK: descpb.ColumnIDs{2, 1}, expected: []string{"t_c", "t_d_old_storing"}},
+		{newPK: descpb.ColumnIDs{3, 1}, expected: []string{"t_b", "t_b_a", "t_d_b", "t_d_old_storing"}},
+		// The new primary index is never reported.
+		{newPK: descpb.ColumnIDs{3}, expected: []string{"t_b", "t_b_a", "t_c", "t_d_b", "t_d_old_storing"}},
+	} {
+		t.Run(fmt.Sprint(tc.newPK), func(t *testing.T) {
+			var actual []string
+			for _, idx := range catalog.IndexesRequiringRewriteForNewPK(desc, tc.newPK) {
+				actual = append(actual, idx.GetName())
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestDiffIndexes(t *testing.T) {
+	pkey := makeTestIndex(1, "t_pkey", 1)
+	pkey.Unique = true
+	withSuffix := func(idx descpb.IndexDescriptor) descpb.IndexDescriptor {
+		idx.KeySuffixColumnIDs = []descpb.ColumnID{1}
+		return idx
+	}
+	before := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: pkey,
+		Indexes: []descpb.IndexDescriptor{
+			withSuffix(makeTestIndex(2, "t_b", 2)),
+			withSuffix(makeTestIndex(3, "t_c", 3)),
+		},
+	})
+
+	// Rename the primary index, add storing to t_b, drop t_c and add t_d.
+	renamedPKey := pkey
+	renamedPKey.Name = "t_pkey_renamed"
+	storing := withSuffix(makeTestIndex(2, "t_b", 2))
+	storing.StoreColumnIDs = []descpb.ColumnID{4}
+	after := buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a", "b", "c", "d"),
+		PrimaryIndex: renamedPKey,
+		Indexes: []descpb.IndexDescriptor{
+			storing,
+			withSuffix(makeTestIndex(4, "t_d", 4)),
+		},
+	})
+
+	names := func(indexes []catalog.Index) []string {
+		var ret []string
+		for _, idx := range indexes {
+			ret = append(ret, idx.GetName())
+		}
+		return ret
+	}
+	added, dropped, changed := catalog.DiffIndexes(before, after)
+	require.Equal(t, []string{"t_d"}, names(added))
+	require.Equal(t, []string{"t_c"}, names(dropped))
+	require.Equal(t, []string{"t_b"}, names(changed))
+
+	// Reversing the diff swaps added and dropped indexes.
+	added, dropped, changed = catalog.DiffIndexes(after, before)
+	require.Equal(t, []string{"t_c"}, names(added))
+	require.Equal(t, []string{"t_d"}, names(dropped))
+	require.Equal(t, []string{"t_b"}, names(changed))
+
+	// Identical descriptors have no differences.
+	added, dropped, changed = catalog.DiffIndexes(before, before)
+	require.Empty(t, added)
+	require.Empty(t, dropped)
+	require.Empty(t, changed)
+
+	// Neither does renaming the column a hash sharded index is computed over.
+	sharded := makeShardedTestTable()
+	added, dropped, changed = catalog.DiffIndexes(sharded, renameTestColumn(t, sharded, "b", "b_renamed"))
+	require.Empty(t, added)
+	require.Empty(t, dropped)
+	require.Empty(t, changed)
+}
+
+func TestFindColumnByPGAttributeNum(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d", "e")
+	// Column e replaced column d, e.g. by an ALTER COLUMN TYPE, and took over
+	// its attribute number.
+	cols[4].PGAttributeNum = 4
+	dropped := cols[1]
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      []descpb.ColumnDescriptor{cols[0], cols[2], cols[4]},
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Mutations: []descpb.DescriptorMutation{
+			{
+				Descriptor_: &descpb.DescriptorMutation_Column{Column: &dropped},
+				State:       descpb.DescriptorMutation_WRITE_ONLY,
+				Direction:   descpb.DescriptorMutation_DROP,
+			},
+		},
+	})
+
+	for _, tc := range []struct {
+		attNum   descpb.PGAttributeNum
+		expected string
+	}{
+		{attNum: 1, expected: "a"},
+		{attNum: 2, expected: "b"},
+		{attNum: 3, expected: "c"},
+		{attNum: 4, expected: "e"},
+		// Attribute numbers are not column IDs.
+		{attNum: 5, expected: ""},
+		{attNum: 6, expected: ""},
+	} {
+		t.Run(fmt.Sprint(tc.attNum), func(t *testing.T) {
+			col := catalog.FindColumnByPGAttributeNum(desc, tc.attNum)
+			if tc.expected == "" {
+				require.Nil(t, col)
+				_, err := catalog.MustFindColumnByPGAttributeNum(desc, tc.attNum)
+				require.Error(t, err)
+				return
+			}
+			require.NotNil(t, col)
+			require.Equal(t, tc.expected, col.GetName())
+			require.Equal(t, tc.expected == "b", col.Dropped())
+		})
+	}
+}
+
+func TestAllSequenceIDsUsedByColumns(t *testing.T) {
+	cols := makeTestColumns("a", "b", "c", "d")
+	// a is a SERIAL column backed by sequence 110, which it owns.
+	cols[0].OwnsSequenceIds = []descpb.ID{110}
+	cols[0].UsesSequenceIds = []descpb.ID{110}
+	// b's default expression uses two sequences, one of which it owns.
+	cols[1].OwnsSequenceIds = []descpb.ID{105}
+	cols[1].UsesSequenceIds = []descpb.ID{120, 105}
+	// c uses the sequence owned by a.
+	cols[2].UsesSequenceIds = []descpb.ID{110}
+	// d owns a sequence without using it, and is being dropped.
+	dropped := cols[3]
+	dropped.OwnsSequenceIds = []descpb.ID{130}
+	desc := buildTestTable(descpb.TableDescriptor{
+		Columns:      cols[:3],
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+		Mutations: []descpb.DescriptorMutation{
+			{
+				Descriptor_: &descpb.DescriptorMutation_Column{Column: &dropped},
+				State:       descpb.DescriptorMutation_WRITE_ONLY,
+				Direction:   descpb.DescriptorMutation_DROP,
+			},
+		},
+	})
+
+	owned, used := catalog.AllSequenceIDsUsedByColumns(desc)
+	require.Equal(t, []descpb.ID{105, 110, 130}, owned)
+	require.Equal(t, []descpb.ID{105, 110, 120}, used)
+
+	owned, used = catalog.AllSequenceIDsUsedByColumns(buildTestTable(descpb.TableDescriptor{
+		Columns:      makeTestColumns("a"),
+		PrimaryIndex: makeTestIndex(1, "t_pkey", 1),
+	}))
+	require.Empty(t, owned)
+	require.Empty(t, used)
+}
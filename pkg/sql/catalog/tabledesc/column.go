@@ -6,7 +6,10 @@
 package tabledesc
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
@@ -15,7 +18,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/seqexpr"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -30,6 +35,26 @@ type column struct {
 	maybeMutation
 	desc    *descpb.ColumnDescriptor
 	ordinal int
+
+	// onUpdateExpr caches the parsed on update expression. It's only set for
+	// columns which have one, see newOnUpdateExprCache.
+	onUpdateExpr *onUpdateExprCache
+}
+
+// onUpdateExprCache lazily parses the on update expression of a column.
+type onUpdateExprCache struct {
+	once sync.Once
+	expr tree.Expr
+	err  error
+}
+
+// newOnUpdateExprCache returns an onUpdateExprCache for the column, or nil if
+// the column doesn't have an on update expression.
+func newOnUpdateExprCache(desc *descpb.ColumnDescriptor) *onUpdateExprCache {
+	if desc.OnUpdateExpr == nil {
+		return nil
+	}
+	return &onUpdateExprCache{}
 }
 
 // ColumnDesc returns the underlying protobuf descriptor.
@@ -50,6 +75,7 @@ func (w column) DeepCopy() catalog.Column {
 		maybeMutation: w.maybeMutation,
 		desc:          &desc,
 		ordinal:       w.ordinal,
+		onUpdateExpr:  newOnUpdateExprCache(&desc),
 	}
 }
 
@@ -81,6 +107,11 @@ func (w column) ColName() tree.Name {
 	return w.desc.ColName()
 }
 
+// StableIdentity implements the catalog.Column interface.
+func (w column) StableIdentity() string {
+	return fmt.Sprintf("col_%d", w.desc.ID)
+}
+
 // HasType returns true iff the column type is set.
 func (w column) HasType() bool {
 	return w.desc.Type != nil
@@ -91,11 +122,53 @@ func (w column) GetType() *types.T {
 	return w.desc.Type
 }
 
+// TypeFamilyName returns the name of the family of the column type, or the
+// empty string if the column type is not set.
+func (w column) TypeFamilyName() string {
+	if !w.HasType() {
+		return ""
+	}
+	return string(w.desc.Type.Family().Name())
+}
+
+// ArrayElementType implements the catalog.Column interface.
+func (w column) ArrayElementType() (*types.T, bool) {
+	if !w.HasType() || w.desc.Type.Family() != types.ArrayFamily {
+		return nil, false
+	}
+	return w.desc.Type.ArrayContents(), true
+}
+
+// CollationName implements the catalog.Column interface.
+func (w column) CollationName() (string, bool) {
+	if !w.HasType() || w.desc.Type.Family() != types.CollatedStringFamily {
+		return "", false
+	}
+	return w.desc.Type.Locale(), true
+}
+
 // IsNullable returns true iff the column allows NULL values.
 func (w column) IsNullable() bool {
 	return w.desc.Nullable
 }
 
+// IsEffectivelyNotNull implements the catalog.Column interface.
+func (w column) IsEffectivelyNotNull(desc catalog.TableDescriptor) bool {
+	return !w.IsNullable() || desc.GetPrimaryIndex().CollectKeyColumnIDs().Contains(w.GetID())
+}
+
+// IsFamilyPrimaryColumn implements the catalog.Column interface.
+func (w column) IsFamilyPrimaryColumn(desc catalog.TableDescriptor) bool {
+	for _, family := range desc.GetFamilies() {
+		for i, colID := range family.ColumnIDs {
+			if colID == w.GetID() {
+				return i == 0
+			}
+		}
+	}
+	return false
+}
+
 // HasDefault returns true iff the column has a default expression set.
 func (w column) HasDefault() bool {
 	return w.desc.HasDefault()
@@ -123,6 +196,56 @@ func (w column) GetDefaultExpr() string {
 	return *w.desc.DefaultExpr
 }
 
+// DefaultExprReferencesSequence implements the catalog.Column interface.
+func (w column) DefaultExprReferencesSequence(seqID descpb.ID) bool {
+	if !w.HasDefault() {
+		return false
+	}
+	var usesSequence bool
+	for _, id := range w.desc.UsesSequenceIds {
+		usesSequence = usesSequence || id == seqID
+	}
+	if !usesSequence {
+		return false
+	}
+	expr, err := parser.ParseExpr(w.GetDefaultExpr())
+	if err != nil {
+		return false
+	}
+	seqIdentifiers, err := seqexpr.GetUsedSequences(expr)
+	if err != nil {
+		return false
+	}
+	for _, seqIdentifier := range seqIdentifiers {
+		// Sequences referenced by name can't be resolved here, so we err on the
+		// side of caution and assume that it's the sequence in question, given
+		// that the column is known to use it.
+		if !seqIdentifier.IsByID() || descpb.ID(seqIdentifier.SeqID) == seqID {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultExprIsConstant implements the catalog.Column interface.
+func (w column) DefaultExprIsConstant(
+	ctx context.Context, semaCtx *tree.SemaContext,
+) (bool, error) {
+	if !w.HasDefault() {
+		// The column defaults to NULL.
+		return true, nil
+	}
+	expr, err := parser.ParseExpr(w.GetDefaultExpr())
+	if err != nil {
+		return false, err
+	}
+	typedExpr, err := tree.TypeCheck(ctx, expr, semaCtx, types.Any)
+	if err != nil {
+		return false, err
+	}
+	return eval.IsConst(nil /* evalCtx */, typedExpr), nil
+}
+
 // HasOnUpdate returns true iff the column has an on update expression set.
 func (w column) HasOnUpdate() bool {
 	return w.desc.HasOnUpdate()
@@ -137,6 +260,56 @@ func (w column) GetOnUpdateExpr() string {
 	return *w.desc.OnUpdateExpr
 }
 
+// OnUpdateExprTree implements the catalog.Column interface.
+func (w column) OnUpdateExprTree() (tree.Expr, error) {
+	if !w.HasOnUpdate() {
+		return nil, nil
+	}
+	c := w.onUpdateExpr
+	if c == nil {
+		return parser.ParseExpr(w.GetOnUpdateExpr())
+	}
+	c.once.Do(func() {
+		c.expr, c.err = parser.ParseExpr(w.GetOnUpdateExpr())
+	})
+	return c.expr, c.err
+}
+
+// OnUpdateIsCurrentTimestamp returns true iff the column has an on update
+// expression which is a bare current_timestamp() call, i.e. the equivalent of
+// MySQL's ON UPDATE CURRENT_TIMESTAMP.
+func (w column) OnUpdateIsCurrentTimestamp() bool {
+	if !w.HasOnUpdate() {
+		return false
+	}
+	// An unparsable expression is not a current_timestamp() call.
+	expr, err := w.OnUpdateExprTree()
+	if err != nil {
+		return false
+	}
+	funcExpr, ok := asFuncExprNamed(expr, "current_timestamp")
+	return ok && len(funcExpr.Exprs) == 0
+}
+
+// asFuncExprNamed returns expr as a function call, if it is an unresolved call
+// to the function with the given unqualified name, ignoring any surrounding
+// parentheses and type annotation.
+func asFuncExprNamed(expr tree.Expr, name string) (*tree.FuncExpr, bool) {
+	expr = tree.StripParens(expr)
+	if annotated, ok := expr.(*tree.AnnotateTypeExpr); ok {
+		expr = tree.StripParens(annotated.Expr)
+	}
+	funcExpr, ok := expr.(*tree.FuncExpr)
+	if !ok {
+		return nil, false
+	}
+	unresolved, ok := funcExpr.Func.FunctionReference.(*tree.UnresolvedName)
+	if !ok || unresolved.Parts[0] != name {
+		return nil, false
+	}
+	return funcExpr, true
+}
+
 // IsComputed returns true iff the column is a computed column.
 func (w column) IsComputed() bool {
 	return w.desc.IsComputed()
@@ -167,6 +340,13 @@ func (w column) IsExpressionIndexColumn() bool {
 	return w.IsInaccessible() && w.IsVirtual()
 }
 
+// IsHashShardColumn returns true iff the column is the shard column of a
+// non-dropped hash-sharded index of desc. A computed column which merely has
+// the same expression as a shard column isn't one.
+func (w column) IsHashShardColumn(desc catalog.TableDescriptor) bool {
+	return desc.IsShardColumn(w)
+}
+
 // NumUsesSequences returns the number of sequences used by this column.
 func (w column) NumUsesSequences() int {
 	return len(w.desc.UsesSequenceIds)
@@ -245,6 +425,11 @@ func (w column) IsGeneratedByDefaultAsIdentity() bool {
 	return w.desc.GeneratedAsIdentityType == catpb.GeneratedAsIdentityType_GENERATED_BY_DEFAULT
 }
 
+// IsOverridableIdentity implements the catalog.Column interface.
+func (w column) IsOverridableIdentity() bool {
+	return w.IsGeneratedByDefaultAsIdentity()
+}
+
 // GetGeneratedAsIdentityType returns the type of how the column was
 // created as an IDENTITY column.
 // If the column is created with `GENERATED ALWAYS AS IDENTITY` syntax,
@@ -256,6 +441,24 @@ func (w column) GetGeneratedAsIdentityType() catpb.GeneratedAsIdentityType {
 	return w.desc.GeneratedAsIdentityType
 }
 
+// GeneratedIdentitySequenceID returns the ID of the sequence backing the
+// column's `GENERATED AS IDENTITY` values, which is the sequence owned by the
+// column and used in its default expression. Returns ok=false if the column is
+// not an identity column or if it doesn't own any sequence.
+func (w column) GeneratedIdentitySequenceID() (id descpb.ID, ok bool) {
+	if !w.IsGeneratedAsIdentity() || len(w.desc.OwnsSequenceIds) == 0 {
+		return descpb.InvalidID, false
+	}
+	for _, ownedID := range w.desc.OwnsSequenceIds {
+		for _, usedID := range w.desc.UsesSequenceIds {
+			if ownedID == usedID {
+				return ownedID, true
+			}
+		}
+	}
+	return w.desc.OwnsSequenceIds[0], true
+}
+
 // GetGeneratedAsIdentitySequenceOptionStr returns the string representation
 // of the column's `GENERATED AS IDENTITY` sequence option if it exists, empty
 // string otherwise.
@@ -284,6 +487,29 @@ func (w column) GetGeneratedAsIdentitySequenceOption(
 	return seqOpts, nil
 }
 
+// IdentitySequenceParams implements the catalog.Column interface.
+func (w column) IdentitySequenceParams() (start, increment int64, ok bool, err error) {
+	if !w.IsGeneratedAsIdentity() {
+		return 0, 0, false, nil
+	}
+	// The options are validated against the bounds of the sequence, which
+	// depend on the width of the integer column.
+	intSize := int32(64)
+	if typ := w.GetType(); typ != nil && typ.Width() != 0 {
+		intSize = typ.Width()
+	}
+	seqOpts, err := w.GetGeneratedAsIdentitySequenceOption(intSize)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if seqOpts == nil {
+		// Without a customized sequence option, the sequence starts at 1 and
+		// is incremented by 1.
+		return 1, 1, true, nil
+	}
+	return seqOpts.Start, seqOpts.Increment, true, nil
+}
+
 // HasGeneratedAsIdentitySequenceOption returns true if there is a
 // customized sequence option when this column is created as a
 // `GENERATED AS IDENTITY` column.
@@ -328,7 +554,11 @@ func newColumnCache(desc *descpb.TableDescriptor, mutations *mutationCache) *col
 	numPublic := len(desc.Columns)
 	backingStructs := make([]column, numPublic, numPublic+len(colinfo.AllSystemColumnDescs))
 	for i := range desc.Columns {
-		backingStructs[i] = column{desc: &desc.Columns[i], ordinal: i}
+		backingStructs[i] = column{
+			desc:         &desc.Columns[i],
+			ordinal:      i,
+			onUpdateExpr: newOnUpdateExprCache(&desc.Columns[i]),
+		}
 	}
 	numMutations := len(mutations.columns)
 	numDeletable := numPublic + numMutations
@@ -0,0 +1,514 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package tabledesc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnOnUpdateIsCurrentTimestamp(t *testing.T) {
+	for _, tc := range []struct {
+		onUpdate *string
+		expected bool
+	}{
+		{nil, false},
+		{strPtr("current_timestamp():::TIMESTAMPTZ"), true},
+		{strPtr("current_timestamp()"), true},
+		{strPtr("CURRENT_TIMESTAMP"), true},
+		{strPtr("current_timestamp(3:::INT8)"), false},
+		{strPtr("now():::TIMESTAMPTZ"), false},
+		{strPtr("'2024-01-01':::TIMESTAMPTZ"), false},
+	} {
+		col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+			Name: "ts", ID: 1, Type: types.TimestampTZ, Nullable: true, OnUpdateExpr: tc.onUpdate,
+		})
+		require.Equal(t, tc.expected, col.OnUpdateIsCurrentTimestamp(), "%s", col.GetOnUpdateExpr())
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestColumnTypeFamilyName(t *testing.T) {
+	for _, tc := range []struct {
+		typ      *types.T
+		expected string
+	}{
+		{nil, ""},
+		{types.Int, "int"},
+		{types.Int4, "int"},
+		{types.String, "string"},
+		{types.MakeVarChar(10), "string"},
+		{types.Decimal, "decimal"},
+		{types.TimestampTZ, "timestamptz"},
+		{types.Jsonb, "jsonb"},
+		{types.IntArray, "array"},
+	} {
+		col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+			Name: "c", ID: 1, Type: tc.typ,
+		})
+		require.Equal(t, tc.expected, col.TypeFamilyName())
+	}
+}
+
+func TestColumnArrayElementType(t *testing.T) {
+	for _, tc := range []struct {
+		typ      *types.T
+		expected *types.T
+	}{
+		{nil, nil},
+		{types.Int, nil},
+		{types.Jsonb, nil},
+		{types.IntArray, types.Int},
+		{types.StringArray, types.String},
+		{types.MakeArray(types.MakeVarChar(10)), types.MakeVarChar(10)},
+	} {
+		col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+			Name: "c", ID: 1, Type: tc.typ,
+		})
+		elemType, ok := col.ArrayElementType()
+		require.Equal(t, tc.expected != nil, ok)
+		if tc.expected == nil {
+			require.Nil(t, elemType)
+			continue
+		}
+		require.True(t, tc.expected.Identical(elemType), "expected %s, got %s", tc.expected, elemType)
+	}
+}
+
+func TestColumnCollationName(t *testing.T) {
+	for _, tc := range []struct {
+		typ      *types.T
+		expected string
+		ok       bool
+	}{
+		{typ: nil},
+		{typ: types.String},
+		{typ: types.MakeVarChar(10)},
+		{typ: types.Int},
+		{typ: types.MakeCollatedString(types.String, "de"), expected: "de", ok: true},
+		{typ: types.MakeCollatedString(types.MakeVarChar(10), "en_US"), expected: "en_US", ok: true},
+	} {
+		col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+			Name: "c", ID: 1, Type: tc.typ,
+		})
+		name, ok := col.CollationName()
+		require.Equal(t, tc.ok, ok, "%s", tc.typ)
+		require.Equal(t, tc.expected, name, "%s", tc.typ)
+	}
+}
+
+func TestColumnGeneratedIdentitySequenceID(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		identityType catpb.GeneratedAsIdentityType
+		uses, owns   []descpb.ID
+		expectedID   descpb.ID
+		expectedOK   bool
+	}{
+		{
+			name:         "generated always",
+			identityType: catpb.GeneratedAsIdentityType_GENERATED_ALWAYS,
+			uses:         []descpb.ID{105},
+			owns:         []descpb.ID{105},
+			expectedID:   105,
+			expectedOK:   true,
+		},
+		{
+			name:         "generated by default",
+			identityType: catpb.GeneratedAsIdentityType_GENERATED_BY_DEFAULT,
+			uses:         []descpb.ID{104, 106},
+			owns:         []descpb.ID{107, 106},
+			expectedID:   106,
+			expectedOK:   true,
+		},
+		{
+			name:         "not identity",
+			identityType: catpb.GeneratedAsIdentityType_NOT_IDENTITY_COLUMN,
+			uses:         []descpb.ID{105},
+			owns:         []descpb.ID{105},
+		},
+		{
+			name:         "no owned sequence",
+			identityType: catpb.GeneratedAsIdentityType_GENERATED_ALWAYS,
+			uses:         []descpb.ID{105},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+				Name: "c", ID: 1, Type: types.Int,
+				GeneratedAsIdentityType: tc.identityType,
+				UsesSequenceIds:         tc.uses,
+				OwnsSequenceIds:         tc.owns,
+			})
+			id, ok := col.GeneratedIdentitySequenceID()
+			require.Equal(t, tc.expectedOK, ok)
+			require.Equal(t, tc.expectedID, id)
+		})
+	}
+}
+
+func TestColumnIsOverridableIdentity(t *testing.T) {
+	for _, tc := range []struct {
+		identityType catpb.GeneratedAsIdentityType
+		expected     bool
+	}{
+		{identityType: catpb.GeneratedAsIdentityType_NOT_IDENTITY_COLUMN, expected: false},
+		{identityType: catpb.GeneratedAsIdentityType_GENERATED_ALWAYS, expected: false},
+		{identityType: catpb.GeneratedAsIdentityType_GENERATED_BY_DEFAULT, expected: true},
+	} {
+		t.Run(tc.identityType.String(), func(t *testing.T) {
+			col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+				Name: "c", ID: 1, Type: types.Int,
+				GeneratedAsIdentityType: tc.identityType,
+			})
+			require.Equal(t, tc.expected, col.IsOverridableIdentity())
+		})
+	}
+}
+
+func TestColumnDefaultExprReferencesSequence(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		defaultExpr *string
+		uses        []descpb.ID
+		seqID       descpb.ID
+		expected    bool
+	}{
+		{
+			name:  "no default",
+			uses:  []descpb.ID{105},
+			seqID: 105,
+		},
+		{
+			name:        "nextval by ID",
+			defaultExpr: strPtr("nextval(105:::REGCLASS)"),
+			uses:        []descpb.ID{105},
+			seqID:       105,
+			expected:    true,
+		},
+		{
+			name:        "other sequence",
+			defaultExpr: strPtr("nextval(105:::REGCLASS)"),
+			uses:        []descpb.ID{105},
+			seqID:       106,
+		},
+		{
+			name:        "used only outside of default",
+			defaultExpr: strPtr("nextval(105:::REGCLASS)"),
+			uses:        []descpb.ID{105, 106},
+			seqID:       106,
+		},
+		{
+			name:        "nextval by name",
+			defaultExpr: strPtr("nextval('s'::STRING)"),
+			uses:        []descpb.ID{105},
+			seqID:       105,
+			expected:    true,
+		},
+		{
+			name:        "not a sequence",
+			defaultExpr: strPtr("unique_rowid()"),
+			uses:        []descpb.ID{105},
+			seqID:       105,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+				Name: "c", ID: 1, Type: types.Int, DefaultExpr: tc.defaultExpr, UsesSequenceIds: tc.uses,
+			})
+			require.Equal(t, tc.expected, col.DefaultExprReferencesSequence(tc.seqID))
+		})
+	}
+}
+
+func TestColumnIsHashShardColumn(t *testing.T) {
+	shardExpr := "mod(fnv32(md5(crdb_internal.datums_to_bytes(c2))), 8:::INT8)"
+	legacyShardExpr := "mod(fnv32(COALESCE(CAST(c2 AS STRING), '':::STRING)), 4:::INT8)"
+	otherExpr := "c1 + 1:::INT8"
+	cols := makeTestColumns(types.Int, types.Int, types.Int, types.Int, types.Int, types.Int, types.Int)
+	cols[1].Nullable = true
+	cols[2].Name, cols[2].Hidden, cols[2].Virtual = "crdb_internal_c2_shard_8", true, true
+	cols[2].ComputeExpr = &shardExpr
+	cols[3].Name, cols[3].Hidden = "crdb_internal_c2_shard_4", true
+	cols[3].ComputeExpr = &legacyShardExpr
+	cols[4].Name, cols[4].Hidden, cols[4].Virtual = "h", true, true
+	cols[4].ComputeExpr = &otherExpr
+	cols[5].Name, cols[5].Virtual = "v", true
+	cols[5].ComputeExpr = &shardExpr
+	// A user-defined hidden column with the same expression as a shard column,
+	// which isn't referenced by any hash-sharded index.
+	cols[6].Name, cols[6].Hidden, cols[6].Virtual = "user_shard", true, true
+	cols[6].ComputeExpr = &shardExpr
+	sharded := makeTestIndex(2, "sharded", 3, 2)
+	sharded.Sharded = catpb.ShardedDescriptor{
+		IsSharded:    true,
+		Name:         "crdb_internal_c2_shard_8",
+		ShardBuckets: 8,
+		ColumnNames:  []string{"c2"},
+	}
+	legacySharded := makeTestIndex(3, "legacy_sharded", 4, 2)
+	legacySharded.Sharded = catpb.ShardedDescriptor{
+		IsSharded:    true,
+		Name:         "crdb_internal_c2_shard_4",
+		ShardBuckets: 4,
+		ColumnNames:  []string{"c2"},
+	}
+	desc := buildTestTable(makeTestTable(cols, sharded, legacySharded))
+
+	expected := map[string]bool{
+		"crdb_internal_c2_shard_8": true,
+		"crdb_internal_c2_shard_4": true,
+	}
+	for _, col := range desc.AllColumns() {
+		require.Equal(t, expected[col.GetName()], col.IsHashShardColumn(desc), col.GetName())
+	}
+}
+
+func TestColumnHasNullDefault(t *testing.T) {
+	for _, tc := range []struct {
+		defaultExpr *string
+		expected    bool
+	}{
+		// No default at all, which is implicitly NULL.
+		{defaultExpr: nil, expected: false},
+		{defaultExpr: strPtr("NULL"), expected: true},
+		// Only the NULL literal itself is considered.
+		{defaultExpr: strPtr("NULL::INT8"), expected: false},
+		{defaultExpr: strPtr("42:::INT8"), expected: false},
+		// Unparsable defaults aren't NULL.
+		{defaultExpr: strPtr("1 +"), expected: false},
+	} {
+		col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+			Name: "c", ID: 1, Type: types.Int, Nullable: true, DefaultExpr: tc.defaultExpr,
+		})
+		require.Equal(t, tc.expected, col.HasNullDefault(), "%s", col.GetDefaultExpr())
+	}
+}
+
+func TestColumnDefaultExprIsConstant(t *testing.T) {
+	ctx := context.Background()
+	enumType := types.MakeEnum(catid.TypeIDToOID(100), catid.TypeIDToOID(101))
+	enumType.TypeMeta = types.UserDefinedTypeMetadata{
+		Name: &types.UserDefinedTypeName{Schema: "public", Name: "typ"},
+		EnumData: &types.EnumMetadata{
+			LogicalRepresentations:  []string{"a", "b"},
+			PhysicalRepresentations: [][]byte{{0x40}, {0x80}},
+			IsMemberReadOnly:        []bool{false, false},
+		},
+	}
+	semaCtx := tree.MakeSemaContext(tree.MakeTestingMapTypeResolver(map[string]*types.T{
+		"typ": enumType,
+	}))
+	for _, tc := range []struct {
+		defaultExpr *string
+		typ         *types.T
+		expected    bool
+		err         bool
+	}{
+		{defaultExpr: nil, typ: types.Int, expected: true},
+		{defaultExpr: strPtr("NULL"), typ: types.Int, expected: true},
+		{defaultExpr: strPtr("42:::INT8"), typ: types.Int, expected: true},
+		{defaultExpr: strPtr("1:::INT8 + 2:::INT8"), typ: types.Int, expected: true},
+		{defaultExpr: strPtr("lower('FOO':::STRING)"), typ: types.String, expected: true},
+		{defaultExpr: strPtr("'a':::typ"), typ: enumType, expected: true},
+		{defaultExpr: strPtr("now():::TIMESTAMPTZ"), typ: types.TimestampTZ, expected: false},
+		{defaultExpr: strPtr("unique_rowid()"), typ: types.Int, expected: false},
+		{defaultExpr: strPtr("gen_random_uuid()"), typ: types.Uuid, expected: false},
+		{defaultExpr: strPtr("nextval(105:::REGCLASS)"), typ: types.Int, expected: false},
+		{defaultExpr: strPtr("1 +"), typ: types.Int, err: true},
+	} {
+		col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+			Name: "c", ID: 1, Type: tc.typ, Nullable: true, DefaultExpr: tc.defaultExpr,
+		})
+		actual, err := col.DefaultExprIsConstant(ctx, &semaCtx)
+		if tc.err {
+			require.Error(t, err, "%s", col.GetDefaultExpr())
+			continue
+		}
+		require.NoError(t, err, "%s", col.GetDefaultExpr())
+		require.Equal(t, tc.expected, actual, "%s", col.GetDefaultExpr())
+	}
+}
+
+func TestColumnIdentitySequenceParams(t *testing.T) {
+	for _, tc := range []struct {
+		name                       string
+		identityType               catpb.GeneratedAsIdentityType
+		typ                        *types.T
+		seqOption                  *string
+		expectedStart, expectedInc int64
+		expectedOK                 bool
+		err                        bool
+	}{
+		{
+			name:         "not identity",
+			identityType: catpb.GeneratedAsIdentityType_NOT_IDENTITY_COLUMN,
+			typ:          types.Int,
+			seqOption:    strPtr("START WITH 10 INCREMENT BY 5"),
+		},
+		{
+			name:          "default options",
+			identityType:  catpb.GeneratedAsIdentityType_GENERATED_ALWAYS,
+			typ:           types.Int,
+			expectedStart: 1,
+			expectedInc:   1,
+			expectedOK:    true,
+		},
+		{
+			name:          "custom start and increment",
+			identityType:  catpb.GeneratedAsIdentityType_GENERATED_BY_DEFAULT,
+			typ:           types.Int,
+			seqOption:     strPtr("START WITH 10 INCREMENT BY 5"),
+			expectedStart: 10,
+			expectedInc:   5,
+			expectedOK:    true,
+		},
+		{
+			name:          "descending",
+			identityType:  catpb.GeneratedAsIdentityType_GENERATED_ALWAYS,
+			typ:           types.Int4,
+			seqOption:     strPtr("INCREMENT BY -2"),
+			expectedStart: -1,
+			expectedInc:   -2,
+			expectedOK:    true,
+		},
+		{
+			name:         "start out of bounds",
+			identityType: catpb.GeneratedAsIdentityType_GENERATED_ALWAYS,
+			typ:          types.Int2,
+			seqOption:    strPtr("START WITH 100000"),
+			err:          true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+				Name: "c", ID: 1, Type: tc.typ,
+				GeneratedAsIdentityType:           tc.identityType,
+				GeneratedAsIdentitySequenceOption: tc.seqOption,
+			})
+			start, inc, ok, err := col.IdentitySequenceParams()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOK, ok)
+			require.Equal(t, tc.expectedStart, start)
+			require.Equal(t, tc.expectedInc, inc)
+		})
+	}
+}
+
+func TestColumnStableIdentity(t *testing.T) {
+	colDesc := &descpb.ColumnDescriptor{Name: "a", ID: 7, Type: types.Int}
+	before := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, colDesc)
+	require.Equal(t, "col_7", before.StableIdentity())
+
+	// Simulate a rename of the column.
+	renamed := *colDesc
+	renamed.Name = "b"
+	after := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &renamed)
+	require.Equal(t, "b", after.GetName())
+	require.Equal(t, before.StableIdentity(), after.StableIdentity())
+}
+
+func TestColumnIsEffectivelyNotNull(t *testing.T) {
+	cols := makeTestColumns(types.Int, types.Int, types.Int)
+	// The primary key column lacks an explicit NOT NULL flag.
+	cols[0].Name, cols[0].Nullable = "pk", true
+	cols[1].Name = "not_null"
+	cols[2].Name, cols[2].Nullable = "nullable", true
+	desc := buildTestTable(makeTestTable(cols))
+
+	expected := map[string]bool{
+		"pk":       true,
+		"not_null": true,
+		"nullable": false,
+	}
+	for _, col := range desc.PublicColumns() {
+		require.Equal(t, expected[col.GetName()], col.IsEffectivelyNotNull(desc), col.GetName())
+	}
+}
+
+func TestColumnIsFamilyPrimaryColumn(t *testing.T) {
+	virtualExpr := "a + b"
+	cols := makeTestColumns(types.Int, types.Int, types.Int, types.Int, types.Int, types.Int)
+	for i, name := range []string{"a", "b", "c", "d", "e", "v"} {
+		cols[i].Name = name
+		cols[i].Nullable = i > 0
+	}
+	cols[5].ComputeExpr, cols[5].Virtual = &virtualExpr, true
+	tableDesc := makeTestTable(cols)
+	tableDesc.Families = []descpb.ColumnFamilyDescriptor{
+		{ID: 0, Name: "primary", ColumnIDs: []descpb.ColumnID{1, 2}, ColumnNames: []string{"a", "b"}},
+		{ID: 1, Name: "fam_d_c", ColumnIDs: []descpb.ColumnID{4, 3}, ColumnNames: []string{"d", "c"}},
+		{ID: 2, Name: "fam_e", ColumnIDs: []descpb.ColumnID{5}, ColumnNames: []string{"e"}},
+	}
+	desc := buildTestTable(tableDesc)
+
+	expected := map[string]bool{
+		"a": true,
+		"b": false,
+		// The first column of a family is the first in its column list, which
+		// needn't be the one with the lowest ID.
+		"c": false,
+		"d": true,
+		"e": true,
+		// Virtual columns don't belong to any family.
+		"v": false,
+	}
+	for _, col := range desc.PublicColumns() {
+		require.Equal(t, expected[col.GetName()], col.IsFamilyPrimaryColumn(desc), col.GetName())
+	}
+}
+
+func TestColumnOnUpdateExprTree(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		onUpdate *string
+		expected string
+		err      bool
+	}{
+		{name: "unset"},
+		{name: "valid", onUpdate: strPtr("now():::TIMESTAMPTZ"), expected: "now():::TIMESTAMPTZ"},
+		{name: "malformed", onUpdate: strPtr("now(("), err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			col := tabledesc.TestingMakeColumn(descpb.DescriptorMutation_NONE, &descpb.ColumnDescriptor{
+				Name: "c", ID: 1, Type: types.TimestampTZ, OnUpdateExpr: tc.onUpdate,
+			})
+			expr, err := col.OnUpdateExprTree()
+			if tc.err {
+				require.Error(t, err)
+				// The error is cached along with the expression.
+				_, err2 := col.OnUpdateExprTree()
+				require.Equal(t, err, err2)
+				return
+			}
+			require.NoError(t, err)
+			if tc.onUpdate == nil {
+				require.Nil(t, expr)
+				return
+			}
+			require.Equal(t, tc.expected, tree.Serialize(expr))
+			// Subsequent calls return the cached expression.
+			expr2, err := col.OnUpdateExprTree()
+			require.NoError(t, err)
+			require.Same(t, expr, expr2)
+		})
+	}
+}
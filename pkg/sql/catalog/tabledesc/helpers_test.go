@@ -71,5 +71,6 @@ func TestingMakeColumn(
 		maybeMutation: maybeMutation{mutationDirection: direction},
 		desc:          desc,
 		ordinal:       0,
+		onUpdateExpr:  newOnUpdateExprCache(desc),
 	}
 }
@@ -6,15 +6,22 @@
 package tabledesc
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/iterutil"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -36,6 +43,17 @@ type index struct {
 	ordinal int
 }
 
+// MakeIndexFromDescriptor wraps a deep copy of a standalone index descriptor,
+// i.e. one which isn't necessarily part of any table descriptor, in a public
+// catalog.Index with the given ordinal. The index is primary iff the ordinal
+// is 0. This is useful for handing an index descriptor to read-only APIs.
+func MakeIndexFromDescriptor(desc descpb.IndexDescriptor, ordinal int) catalog.Index {
+	return &index{
+		desc:    protoutil.Clone(&desc).(*descpb.IndexDescriptor),
+		ordinal: ordinal,
+	}
+}
+
 // IndexDesc returns the underlying protobuf descriptor.
 // Ideally, this method should be called as rarely as possible.
 func (w index) IndexDesc() *descpb.IndexDescriptor {
@@ -159,12 +177,167 @@ func (w index) ExplicitColumnStartIdx() int {
 	return w.desc.ExplicitColumnStartIdx()
 }
 
+// IsPartitioningColumn implements the catalog.Index interface.
+func (w index) IsPartitioningColumn(id descpb.ColumnID) bool {
+	i := w.KeyColumnPosition(id)
+	return i >= 0 && i < w.PartitioningColumnCount()
+}
+
+// PartitionRegionNames implements the catalog.Index interface.
+func (w index) PartitionRegionNames(desc catalog.TableDescriptor) ([]string, error) {
+	part := w.desc.Partitioning
+	// A region-partitioned index is list-partitioned on a single implicit
+	// column, the region column, with one partition per region.
+	if !w.IsRegionalByRowPartitioned(desc) || part.NumImplicitColumns != 1 || part.NumColumns != 1 ||
+		len(part.List) == 0 || len(part.Range) > 0 {
+		return nil, errors.Newf("index %q is not partitioned by region", w.GetName())
+	}
+	names := make([]string, len(part.List))
+	for i := range part.List {
+		l := &part.List[i]
+		if l.Subpartitioning.NumColumns > 0 {
+			return nil, errors.Newf("index %q is not partitioned by region", w.GetName())
+		}
+		names[i] = l.Name
+	}
+	return names, nil
+}
+
+// AllPartitionNames implements the catalog.Index interface.
+func (w index) AllPartitionNames() (names []string) {
+	_ = w.GetPartitioning().ForEachPartitionName(func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	return names
+}
+
+// IsRegionalByRowPartitioned implements the catalog.Index interface.
+func (w index) IsRegionalByRowPartitioned(desc catalog.TableDescriptor) bool {
+	if !desc.IsLocalityRegionalByRow() || w.ImplicitPartitioningColumnCount() == 0 {
+		return false
+	}
+	regionColName, err := desc.GetRegionalByRowTableRegionColumnName()
+	if err != nil {
+		return false
+	}
+	regionCol := catalog.FindColumnByTreeName(desc, regionColName)
+	return regionCol != nil && w.GetKeyColumnID(0) == regionCol.GetID()
+}
+
 // IsValidOriginIndex implements the catalog.Index interface.
 func (w index) IsValidOriginIndex(fk catalog.ForeignKeyConstraint) bool {
+	return w.isValidOriginForColumns(fk.ForeignKeyDesc().OriginColumnIDs)
+}
+
+// CanBeOriginForColumns implements the catalog.Index interface.
+func (w index) CanBeOriginForColumns(colIDs descpb.ColumnIDs) bool {
+	if w.IsMutation() && !w.WriteAndDeleteOnly() {
+		return false
+	}
+	return w.isValidOriginForColumns(colIDs)
+}
+
+func (w index) isValidOriginForColumns(colIDs descpb.ColumnIDs) bool {
 	if w.IsPartial() {
 		return false
 	}
-	return descpb.ColumnIDs(w.desc.KeyColumnIDs).HasPrefix(fk.ForeignKeyDesc().OriginColumnIDs)
+	return descpb.ColumnIDs(w.desc.KeyColumnIDs).HasPrefix(colIDs)
+}
+
+// PredicateReferencesColumn returns true iff the index is partial and its
+// predicate references the column with the given ID in desc. An error is
+// returned if the predicate can't be parsed or refers to unknown columns.
+func (w index) PredicateReferencesColumn(
+	desc catalog.TableDescriptor, colID descpb.ColumnID,
+) (bool, error) {
+	colIDs, err := w.predicateColumnIDSet(desc)
+	if err != nil {
+		return false, err
+	}
+	return colIDs.Contains(colID), nil
+}
+
+// PredicateColumnIDs returns the IDs of the columns in desc referenced by the
+// predicate of the index, in increasing order, or nil if the index isn't
+// partial. An error is returned if the predicate can't be parsed or refers to
+// unknown columns.
+func (w index) PredicateColumnIDs(desc catalog.TableDescriptor) (descpb.ColumnIDs, error) {
+	colIDs, err := w.predicateColumnIDSet(desc)
+	if err != nil {
+		return nil, err
+	}
+	return colIDs.Ordered(), nil
+}
+
+// PredicateIsImmutable implements the catalog.Index interface.
+func (w index) PredicateIsImmutable(
+	ctx context.Context, semaCtx *tree.SemaContext, desc catalog.TableDescriptor,
+) (bool, error) {
+	if !w.IsPartial() {
+		return true, nil
+	}
+	expr, err := parser.ParseExpr(w.GetPredicate())
+	if err != nil {
+		return false, err
+	}
+	expr, _, err = schemaexpr.ReplaceColumnVars(expr, func(
+		columnName tree.Name,
+	) (exists bool, accessible bool, id descpb.ColumnID, typ *types.T) {
+		col := catalog.FindColumnByTreeName(desc, columnName)
+		if col == nil || col.Dropped() {
+			return false, false, 0, nil
+		}
+		return true, !col.IsInaccessible(), col.GetID(), col.GetType()
+	})
+	if err != nil {
+		return false, err
+	}
+	// Type-check the predicate without any volatility restriction first, so
+	// that errors unrelated to volatility are surfaced. Any error from the
+	// second, restricted pass is then due to a stable or volatile operator.
+	defer semaCtx.Properties.Restore(semaCtx.Properties)
+	semaCtx.Properties.Require(string(tree.IndexPredicateExpr), tree.RejectSpecial)
+	if _, err := tree.TypeCheck(ctx, expr, semaCtx, types.Bool); err != nil {
+		return false, err
+	}
+	semaCtx.Properties.Require(
+		string(tree.IndexPredicateExpr),
+		tree.RejectSpecial|tree.RejectStableOperators|tree.RejectVolatileFunctions,
+	)
+	if _, err := tree.TypeCheck(ctx, expr, semaCtx, types.Bool); err != nil {
+		return false, nil //nolint:returnerrcheck
+	}
+	return true, nil
+}
+
+// ExpressionColumnExprs implements the catalog.Index interface.
+func (w index) ExpressionColumnExprs(desc catalog.TableDescriptor) ([]string, error) {
+	var exprs []string
+	for _, colID := range w.desc.KeyColumnIDs {
+		col, err := catalog.MustFindColumnByID(desc, colID)
+		if err != nil {
+			return nil, err
+		}
+		if col.IsExpressionIndexColumn() {
+			exprs = append(exprs, col.GetComputeExpr())
+		}
+	}
+	return exprs, nil
+}
+
+// predicateColumnIDSet returns the set of IDs of the columns in desc
+// referenced by the predicate of the index, which is empty if the index isn't
+// partial.
+func (w index) predicateColumnIDSet(desc catalog.TableDescriptor) (catalog.TableColSet, error) {
+	if !w.IsPartial() {
+		return catalog.TableColSet{}, nil
+	}
+	expr, err := parser.ParseExpr(w.GetPredicate())
+	if err != nil {
+		return catalog.TableColSet{}, err
+	}
+	return schemaexpr.ExtractColumnIDs(desc, expr)
 }
 
 // IsValidReferencedUniqueConstraint implements the catalog.UniqueConstraint
@@ -206,6 +379,14 @@ func (w index) InvertedColumnKeyType() *types.T {
 	return w.desc.InvertedColumnKeyType()
 }
 
+// InvertedColumnKeyTypeSafe implements the catalog.Index interface.
+func (w index) InvertedColumnKeyTypeSafe() (*types.T, bool) {
+	if w.desc.Type != descpb.IndexDescriptor_INVERTED {
+		return nil, false
+	}
+	return w.desc.InvertedColumnKeyType(), true
+}
+
 // InvertedColumnKind returns the kind of the inverted column of the inverted
 // index.
 //
@@ -246,6 +427,16 @@ func (w index) CollectSecondaryStoredColumnIDs() catalog.TableColSet {
 	return catalog.MakeTableColSet(w.desc.StoreColumnIDs...)
 }
 
+// HasStoredColumn implements the catalog.Index interface.
+func (w index) HasStoredColumn(id descpb.ColumnID) bool {
+	for _, colID := range w.desc.StoreColumnIDs {
+		if colID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // CollectKeySuffixColumnIDs creates a new set containing the key suffix column
 // IDs in this index. These are the columns from the table's primary index which
 // are otherwise not in this index.
@@ -259,6 +450,142 @@ func (w index) CollectCompositeColumnIDs() catalog.TableColSet {
 	return catalog.MakeTableColSet(w.desc.CompositeColumnIDs...)
 }
 
+// RedundantStoredColumnIDs implements the catalog.Index interface.
+func (w index) RedundantStoredColumnIDs() catalog.TableColSet {
+	keyColIDs := w.CollectKeyColumnIDs()
+	keyColIDs.UnionWith(w.CollectKeySuffixColumnIDs())
+	return w.CollectSecondaryStoredColumnIDs().Intersection(keyColIDs)
+}
+
+// NumColumns returns the number of distinct columns physically involved in the
+// index, i.e. the union of its key, key suffix, stored and composite columns.
+// For the primary index, which stores all non-virtual columns, this is every
+// column stored in the table.
+func (w index) NumColumns() int {
+	return w.collectColumnIDs().Len()
+}
+
+// collectColumnIDs returns the set of columns physically involved in the
+// index, see NumColumns.
+func (w index) collectColumnIDs() catalog.TableColSet {
+	colIDs := w.CollectKeyColumnIDs()
+	colIDs.UnionWith(w.CollectKeySuffixColumnIDs())
+	colIDs.UnionWith(catalog.MakeTableColSet(w.desc.StoreColumnIDs...))
+	colIDs.UnionWith(w.CollectCompositeColumnIDs())
+	return colIDs
+}
+
+// EstimatedRowWidth implements the catalog.Index interface.
+func (w index) EstimatedRowWidth(desc catalog.TableDescriptor) int {
+	var width int
+	for _, colID := range w.collectColumnIDs().Ordered() {
+		if col := catalog.FindColumnByID(desc, colID); col != nil {
+			width += estimatedColumnWidth(col.GetType())
+		}
+	}
+	return width
+}
+
+// CoversAllColumns implements the catalog.Index interface.
+func (w index) CoversAllColumns(desc catalog.TableDescriptor) bool {
+	colIDs := w.ReadableColumnIDs(desc)
+	for _, col := range desc.PublicColumns() {
+		if !col.IsVirtual() && !colIDs.Contains(col.GetID()) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadableColumnIDs implements the catalog.Index interface.
+func (w index) ReadableColumnIDs(desc catalog.TableDescriptor) catalog.TableColSet {
+	colIDs := w.collectColumnIDs()
+	if w.Primary() {
+		for _, col := range desc.AllColumns() {
+			if !col.IsVirtual() && !col.IsSystemColumn() {
+				colIDs.Add(col.GetID())
+			}
+		}
+	}
+	return colIDs
+}
+
+// SpanForKeyPrefix implements the catalog.Index interface.
+func (w index) SpanForKeyPrefix(
+	codec keys.SQLCodec, desc catalog.TableDescriptor, prefix tree.Datums,
+) (roachpb.Span, error) {
+	if len(prefix) > w.NumKeyColumns() {
+		return roachpb.Span{}, errors.AssertionFailedf(
+			"index %q has %d key columns, got %d prefix values",
+			w.GetName(), w.NumKeyColumns(), len(prefix))
+	}
+	if w.GetType() == descpb.IndexDescriptor_INVERTED && len(prefix) == w.NumKeyColumns() {
+		return roachpb.Span{}, errors.AssertionFailedf(
+			"cannot encode a prefix value for inverted column of index %q", w.GetName())
+	}
+	var colMap catalog.TableColMap
+	for i, val := range prefix {
+		col, err := catalog.MustFindColumnByID(desc, w.GetKeyColumnID(i))
+		if err != nil {
+			return roachpb.Span{}, err
+		}
+		if val != tree.DNull && !val.ResolvedType().Equivalent(col.GetType()) {
+			return roachpb.Span{}, errors.AssertionFailedf(
+				"prefix value %s of type %s doesn't match type %s of column %q",
+				val, val.ResolvedType().SQLStringForError(), col.GetType().SQLStringForError(),
+				col.GetName())
+		}
+		colMap.Set(col.GetID(), i)
+	}
+	keyPrefix := rowenc.MakeIndexKeyPrefix(codec, desc.GetID(), w.GetID())
+	key, err := rowenc.EncodeColumns(
+		w.desc.KeyColumnIDs[:len(prefix)], w.desc.KeyColumnDirections, colMap, prefix, keyPrefix,
+	)
+	if err != nil {
+		return roachpb.Span{}, err
+	}
+	return roachpb.Span{Key: key, EndKey: roachpb.Key(key).PrefixEnd()}, nil
+}
+
+// variableWidthColumnEstimate is the number of bytes assumed for a value of
+// a variable-width type without a declared maximum width.
+const variableWidthColumnEstimate = 64
+
+// estimatedColumnWidth returns an estimate of the number of bytes taken up by
+// a value of type typ.
+func estimatedColumnWidth(typ *types.T) int {
+	switch typ.Family() {
+	case types.BoolFamily:
+		return 1
+	case types.IntFamily:
+		if typ.Width() != 0 {
+			return int(typ.Width() / 8)
+		}
+		return 8
+	case types.FloatFamily:
+		return 8
+	case types.OidFamily, types.DateFamily:
+		return 4
+	case types.TimeFamily, types.TimestampFamily, types.TimestampTZFamily:
+		return 8
+	case types.TimeTZFamily:
+		return 12
+	case types.IntervalFamily, types.UUIDFamily, types.DecimalFamily:
+		return 16
+	case types.StringFamily, types.CollatedStringFamily, types.BytesFamily:
+		// STRING(n), VARCHAR(n) and CHAR(n) have a maximum width in characters,
+		// each of which takes up to 4 bytes in UTF-8.
+		if typ.Width() != 0 {
+			return int(typ.Width()) * 4
+		}
+		return variableWidthColumnEstimate
+	case types.EnumFamily:
+		return 4
+	default:
+		return variableWidthColumnEstimate
+	}
+}
+
 // GetGeoConfig returns the geo config in the index descriptor.
 func (w index) GetGeoConfig() geopb.Config {
 	return w.desc.GeoConfig
@@ -275,6 +602,41 @@ func (w index) GetShardColumnName() string {
 	return w.desc.Sharded.Name
 }
 
+// ShardColumn implements the catalog.Index interface.
+func (w index) ShardColumn(desc catalog.TableDescriptor) (catalog.Column, bool) {
+	if !w.IsSharded() {
+		return nil, false
+	}
+	col := catalog.FindColumnByName(desc, w.GetShardColumnName())
+	return col, col != nil
+}
+
+// ShardColumnIDs implements the catalog.Index interface.
+func (w index) ShardColumnIDs() (descpb.ColumnIDs, error) {
+	if !w.IsSharded() {
+		return nil, nil
+	}
+	// The columns which the shard column is computed over are key columns of
+	// the index, so their IDs can be looked up by name among them.
+	ret := make(descpb.ColumnIDs, 0, len(w.desc.Sharded.ColumnNames))
+	for _, name := range w.desc.Sharded.ColumnNames {
+		found := false
+		for i, keyColName := range w.desc.KeyColumnNames {
+			if keyColName == name {
+				ret = append(ret, w.desc.KeyColumnIDs[i])
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.AssertionFailedf(
+				"sharded column %q of index %q is not a key column", name, w.GetName(),
+			)
+		}
+	}
+	return ret, nil
+}
+
 // GetVersion returns the version of the index descriptor.
 func (w index) GetVersion() descpb.IndexDescriptorVersion {
 	return w.desc.Version
@@ -314,6 +676,34 @@ func (w index) GetKeyColumnDirection(columnOrdinal int) catenumpb.IndexColumn_Di
 	return w.desc.KeyColumnDirections[columnOrdinal]
 }
 
+// KeyColumnPosition implements the catalog.Index interface.
+func (w index) KeyColumnPosition(id descpb.ColumnID) int {
+	for i, colID := range w.desc.KeyColumnIDs {
+		if colID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// DirectionForKeyColumn implements the catalog.Index interface.
+func (w index) DirectionForKeyColumn(id descpb.ColumnID) (catenumpb.IndexColumn_Direction, bool) {
+	i := w.KeyColumnPosition(id)
+	if i < 0 {
+		return 0, false
+	}
+	return w.desc.KeyColumnDirections[i], true
+}
+
+// KeyColumnIDToPositionMap implements the catalog.Index interface.
+func (w index) KeyColumnIDToPositionMap() catalog.TableColMap {
+	var m catalog.TableColMap
+	for i, colID := range w.desc.KeyColumnIDs {
+		m.Set(colID, i)
+	}
+	return m
+}
+
 // NumPrimaryStoredColumns returns the number of columns which the index
 // stores in addition to the columns which are part of the primary key.
 // Returns 0 if the index isn't primary.
@@ -344,6 +734,22 @@ func (w index) GetStoredColumnName(storedColumnOrdinal int) string {
 	return w.desc.StoreColumnNames[storedColumnOrdinal]
 }
 
+// StoredColumnOrdinals returns, for each stored column of the index, the
+// ordinal of that column in desc.AllColumns(). Stored columns which can't be
+// found in desc are mapped to -1.
+func (w index) StoredColumnOrdinals(desc catalog.TableDescriptor) []int {
+	colOrdinals := catalog.ColumnIDToOrdinalMap(desc.AllColumns())
+	ret := make([]int, len(w.desc.StoreColumnIDs))
+	for i, colID := range w.desc.StoreColumnIDs {
+		if ord, ok := colOrdinals.Get(colID); ok {
+			ret[i] = ord
+		} else {
+			ret[i] = -1
+		}
+	}
+	return ret
+}
+
 // NumKeySuffixColumns returns the number of additional columns referenced by
 // the index descriptor, which are not part of the index key but which are part
 // of the table's primary key.
@@ -357,6 +763,39 @@ func (w index) GetKeySuffixColumnID(keySuffixColumnOrdinal int) descpb.ColumnID
 	return w.desc.KeySuffixColumnIDs[keySuffixColumnOrdinal]
 }
 
+// KeySuffixColumnNames implements the catalog.Index interface.
+func (w index) KeySuffixColumnNames(desc catalog.TableDescriptor) []string {
+	if len(w.desc.KeySuffixColumnIDs) == 0 {
+		return nil
+	}
+	names := make([]string, len(w.desc.KeySuffixColumnIDs))
+	for i, colID := range w.desc.KeySuffixColumnIDs {
+		if col := catalog.FindColumnByID(desc, colID); col != nil {
+			names[i] = col.GetName()
+		} else {
+			names[i] = fmt.Sprintf("[%d]", colID)
+		}
+	}
+	return names
+}
+
+// UniquenessSuffixColumnIDs returns the key suffix columns which are
+// implicitly appended to the key of a non-unique secondary index to make it
+// unique. These are the primary key columns which are not already part of the
+// index key: since the primary key is unique, the index key extended with these
+// columns is unique too.
+//
+// Returns an empty slice for primary indexes, whose key is unique by
+// definition, and for unique indexes. Note that unique indexes may still have
+// key suffix columns, which are only used to disambiguate keys containing
+// NULLs.
+func (w index) UniquenessSuffixColumnIDs() descpb.ColumnIDs {
+	if w.Primary() || w.IsUnique() {
+		return descpb.ColumnIDs{}
+	}
+	return append(descpb.ColumnIDs(nil), w.desc.KeySuffixColumnIDs...)
+}
+
 // NumCompositeColumns returns the number of composite columns referenced by the
 // index descriptor.
 func (w index) NumCompositeColumns() int {
@@ -582,6 +1021,22 @@ func (p partitioning) NumRanges() int {
 	return len(p.desc.Range)
 }
 
+// NumLeafPartitions returns the number of partitions which aren't
+// subpartitioned any further, recursively across all levels of nesting.
+func (p partitioning) NumLeafPartitions() int {
+	// Range partitions can't be subpartitioned.
+	n := len(p.desc.Range)
+	for i := range p.desc.List {
+		subp := partitioning{desc: &p.desc.List[i].Subpartitioning}
+		if subp.NumLists() == 0 && subp.NumRanges() == 0 {
+			n++
+		} else {
+			n += subp.NumLeafPartitions()
+		}
+	}
+	return n
+}
+
 // ForEachList applies fn on each list element of the wrapped partitioning.
 // Supports iterutil.StopIteration.
 func (p partitioning) ForEachList(
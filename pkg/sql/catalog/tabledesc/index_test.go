@@ -17,6 +17,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkeys"
@@ -27,11 +29,16 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/desctestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/internal/validate"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc/keyside"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -644,3 +651,1283 @@ func TestSecKeyLatestIndexDescriptorVersion(t *testing.T) {
 	// The index mutation should have its version bumped.
 	require.Equal(t, descpb.StrictIndexColumnIDGuaranteesVersion, newDesc.Mutations[0].GetIndex().Version)
 }
+
+// makeTestColumns returns columns c1, c2, ... with IDs 1, 2, ... and the given
+// types.
+func makeTestColumns(typs ...*types.T) []descpb.ColumnDescriptor {
+	cols := make([]descpb.ColumnDescriptor, len(typs))
+	for i, typ := range typs {
+		cols[i] = descpb.ColumnDescriptor{
+			ID:   descpb.ColumnID(i + 1),
+			Name: fmt.Sprintf("c%d", i+1),
+			Type: typ,
+		}
+	}
+	return cols
+}
+
+// makeTestIndex returns a secondary index descriptor on the given key columns,
+// in ascending order, with c1 as its key suffix. Column names are filled in by
+// buildTestTable.
+func makeTestIndex(
+	id descpb.IndexID, name string, keyColumnIDs ...descpb.ColumnID,
+) descpb.IndexDescriptor {
+	dirs := make([]catenumpb.IndexColumn_Direction, len(keyColumnIDs))
+	for i := range dirs {
+		dirs[i] = catenumpb.IndexColumn_ASC
+	}
+	return descpb.IndexDescriptor{
+		ID:                  id,
+		Name:                name,
+		KeyColumnIDs:        keyColumnIDs,
+		KeyColumnDirections: dirs,
+		KeySuffixColumnIDs:  []descpb.ColumnID{1},
+	}
+}
+
+// makeTestTable returns the descriptor of a table foo with the given columns
+// and secondary indexes, and a primary index foo_pkey on c1 which stores every
+// other non-virtual column. Tests may modify the returned descriptor before
+// passing it to buildTestTable.
+func makeTestTable(
+	cols []descpb.ColumnDescriptor, indexes ...descpb.IndexDescriptor,
+) *descpb.TableDescriptor {
+	desc := &descpb.TableDescriptor{
+		ID:      2,
+		Name:    "foo",
+		Columns: cols,
+		Indexes: indexes,
+	}
+	setTestPrimaryKey(desc, 1)
+	return desc
+}
+
+// setTestPrimaryKey keys the primary index of desc on the given columns, in
+// ascending order, and makes it store every other non-virtual column.
+func setTestPrimaryKey(desc *descpb.TableDescriptor, keyColumnIDs ...descpb.ColumnID) {
+	pk := makeTestIndex(1, "foo_pkey", keyColumnIDs...)
+	pk.KeySuffixColumnIDs = nil
+	pk.EncodingType = catenumpb.PrimaryIndexEncoding
+	keyCols := catalog.MakeTableColSet(keyColumnIDs...)
+	for _, col := range desc.Columns {
+		if !col.Virtual && !keyCols.Contains(col.ID) {
+			pk.StoreColumnIDs = append(pk.StoreColumnIDs, col.ID)
+		}
+	}
+	desc.PrimaryIndex = pk
+}
+
+// buildTestTable fills in the key and stored column names of every index of
+// desc which doesn't have them yet, and builds it.
+func buildTestTable(desc *descpb.TableDescriptor) catalog.TableDescriptor {
+	colNames := make(map[descpb.ColumnID]string, len(desc.Columns))
+	for _, col := range desc.Columns {
+		colNames[col.ID] = col.Name
+	}
+	fillNames := func(idx *descpb.IndexDescriptor) {
+		if idx.KeyColumnNames == nil {
+			for _, colID := range idx.KeyColumnIDs {
+				idx.KeyColumnNames = append(idx.KeyColumnNames, colNames[colID])
+			}
+		}
+		if idx.StoreColumnNames == nil {
+			for _, colID := range idx.StoreColumnIDs {
+				idx.StoreColumnNames = append(idx.StoreColumnNames, colNames[colID])
+			}
+		}
+	}
+	fillNames(&desc.PrimaryIndex)
+	for i := range desc.Indexes {
+		fillNames(&desc.Indexes[i])
+	}
+	for _, m := range desc.Mutations {
+		if idx := m.GetIndex(); idx != nil {
+			fillNames(idx)
+		}
+	}
+	return tabledesc.NewBuilder(desc).BuildImmutableTable()
+}
+
+func TestIndexStoredColumnOrdinals(t *testing.T) {
+	sec := makeTestIndex(2, "sec", 2)
+	sec.StoreColumnIDs = []descpb.ColumnID{5, 3, 4}
+	missing := makeTestIndex(4, "missing", 4)
+	missing.StoreColumnIDs = []descpb.ColumnID{2, 42}
+	missing.StoreColumnNames = []string{"c2", "c42"}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int, types.Int, types.Int),
+		sec, makeTestIndex(3, "nostore", 3), missing,
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected []int
+	}{
+		{"foo_pkey", []int{1, 2, 3, 4}},
+		{"sec", []int{4, 2, 3}},
+		{"nostore", []int{}},
+		{"missing", []int{1, -1}},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.StoredColumnOrdinals(desc))
+		})
+	}
+}
+
+func TestIndexNumColumns(t *testing.T) {
+	sec := makeTestIndex(2, "sec", 2)
+	sec.StoreColumnIDs = []descpb.ColumnID{3}
+	composite := makeTestIndex(3, "composite", 3, 4)
+	composite.CompositeColumnIDs = []descpb.ColumnID{4}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int, types.Int), sec, composite,
+	))
+
+	for _, idx := range desc.AllIndexes() {
+		// The composite columns are always a subset of the key and key suffix
+		// columns, so they don't contribute to the sum.
+		expected := idx.NumKeyColumns() + idx.NumKeySuffixColumns() +
+			idx.NumPrimaryStoredColumns() + idx.NumSecondaryStoredColumns()
+		require.Equal(t, expected, idx.NumColumns(), idx.GetName())
+	}
+	require.Equal(t, len(desc.PublicColumns()), desc.GetPrimaryIndex().NumColumns())
+}
+
+func TestIndexUniquenessSuffixColumnIDs(t *testing.T) {
+	nonUnique := makeTestIndex(2, "non_unique", 3)
+	nonUnique.KeySuffixColumnIDs = []descpb.ColumnID{1, 2}
+	unique := makeTestIndex(4, "unique", 3)
+	unique.Unique = true
+	unique.KeySuffixColumnIDs = []descpb.ColumnID{1, 2}
+	tableDesc := makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int),
+		nonUnique, makeTestIndex(3, "non_unique_overlap", 2), unique,
+	)
+	setTestPrimaryKey(tableDesc, 1, 2)
+	desc := buildTestTable(tableDesc)
+
+	for _, tc := range []struct {
+		index    string
+		expected descpb.ColumnIDs
+	}{
+		{"foo_pkey", descpb.ColumnIDs{}},
+		{"non_unique", descpb.ColumnIDs{1, 2}},
+		{"non_unique_overlap", descpb.ColumnIDs{1}},
+		{"unique", descpb.ColumnIDs{}},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.UniquenessSuffixColumnIDs())
+		})
+	}
+}
+
+func TestIndexPredicateReferencesColumn(t *testing.T) {
+	partial := makeTestIndex(2, "partial", 2)
+	partial.Predicate = "(c3 > 0:::INT8) AND (c4 IS NOT NULL)"
+	unknown := makeTestIndex(4, "unknown", 4)
+	unknown.Predicate = "c5 = 1:::INT8"
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int, types.Int),
+		partial, makeTestIndex(3, "full", 3), unknown,
+	))
+
+	for _, tc := range []struct {
+		index    string
+		colID    descpb.ColumnID
+		expected bool
+		err      bool
+	}{
+		{index: "partial", colID: 1, expected: false},
+		{index: "partial", colID: 2, expected: false},
+		{index: "partial", colID: 3, expected: true},
+		{index: "partial", colID: 4, expected: true},
+		{index: "full", colID: 3, expected: false},
+		{index: "unknown", colID: 4, err: true},
+	} {
+		t.Run(fmt.Sprintf("%s/%d", tc.index, tc.colID), func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			actual, err := idx.PredicateReferencesColumn(desc, tc.colID)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestMakeIndexFromDescriptor(t *testing.T) {
+	desc := descpb.IndexDescriptor{
+		ID:                  2,
+		Name:                "sec",
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{2, 3},
+		KeyColumnNames:      []string{"c2", "c3"},
+		KeyColumnDirections: []catenumpb.IndexColumn_Direction{catenumpb.IndexColumn_ASC, catenumpb.IndexColumn_DESC},
+		KeySuffixColumnIDs:  []descpb.ColumnID{1},
+		StoreColumnIDs:      []descpb.ColumnID{4},
+		StoreColumnNames:    []string{"c4"},
+		Predicate:           "c4 > 0:::INT8",
+		Version:             descpb.LatestIndexDescriptorVersion,
+		EncodingType:        catenumpb.SecondaryIndexEncoding,
+	}
+
+	idx := tabledesc.MakeIndexFromDescriptor(desc, 1)
+	require.Equal(t, desc, *idx.IndexDesc())
+	require.Equal(t, 1, idx.Ordinal())
+	require.False(t, idx.Primary())
+	require.True(t, idx.Public())
+	require.Equal(t, descpb.IndexID(2), idx.GetID())
+	require.Equal(t, "sec", idx.GetName())
+	require.True(t, idx.IsUnique())
+	require.True(t, idx.IsPartial())
+	require.Equal(t, 2, idx.NumKeyColumns())
+	require.Equal(t, "c3", idx.GetKeyColumnName(1))
+	require.Equal(t, catenumpb.IndexColumn_DESC, idx.GetKeyColumnDirection(1))
+	require.Equal(t, catalog.MakeTableColSet(1), idx.CollectKeySuffixColumnIDs())
+	require.Equal(t, catalog.MakeTableColSet(4), idx.CollectSecondaryStoredColumnIDs())
+	require.NotNil(t, idx.AsUniqueWithIndex())
+
+	// The wrapped descriptor is a copy.
+	desc.KeyColumnNames[0] = "renamed"
+	require.Equal(t, "c2", idx.GetKeyColumnName(0))
+
+	// Ordinal 0 denotes a primary index.
+	pk := tabledesc.MakeIndexFromDescriptor(desc, 0)
+	require.True(t, pk.Primary())
+	require.Equal(t, catenumpb.PrimaryIndexEncoding, pk.GetEncodingType())
+}
+
+func TestIndexShardColumn(t *testing.T) {
+	shardExpr := "mod(fnv32(crdb_internal.datums_to_bytes(c2)), 8:::INT8)"
+	sharded := makeTestIndex(2, "sharded", 3, 2)
+	sharded.Sharded = catpb.ShardedDescriptor{
+		IsSharded:    true,
+		Name:         "crdb_internal_c2_shard_8",
+		ShardBuckets: 8,
+		ColumnNames:  []string{"c2"},
+	}
+	desc := buildTestTable(makeTestTable(
+		append(makeTestColumns(types.Int, types.Int), descpb.ColumnDescriptor{
+			ID: 3, Name: "crdb_internal_c2_shard_8", Type: types.Int,
+			Hidden: true, Virtual: true, ComputeExpr: &shardExpr,
+		}),
+		sharded, makeTestIndex(3, "plain", 2),
+	))
+
+	sharded, err := catalog.MustFindIndexByName(desc, "sharded")
+	require.NoError(t, err)
+	col, ok := sharded.ShardColumn(desc)
+	require.True(t, ok)
+	require.Equal(t, descpb.ColumnID(3), col.GetID())
+	require.Equal(t, "crdb_internal_c2_shard_8", col.GetName())
+
+	for _, name := range []string{"foo_pkey", "plain"} {
+		idx, err := catalog.MustFindIndexByName(desc, name)
+		require.NoError(t, err)
+		col, ok := idx.ShardColumn(desc)
+		require.False(t, ok, name)
+		require.Nil(t, col, name)
+	}
+}
+
+func TestIndexShardColumnIDs(t *testing.T) {
+	shardExpr := "mod(fnv32(crdb_internal.datums_to_bytes(c3, c2)), 8:::INT8)"
+	makeDesc := func(shardedColumnNames ...string) catalog.TableDescriptor {
+		sharded := makeTestIndex(2, "sharded", 4, 3, 2)
+		sharded.Sharded = catpb.ShardedDescriptor{
+			IsSharded:    true,
+			Name:         "crdb_internal_c3_c2_shard_8",
+			ShardBuckets: 8,
+			ColumnNames:  shardedColumnNames,
+		}
+		return buildTestTable(makeTestTable(
+			append(makeTestColumns(types.Int, types.Int, types.Int), descpb.ColumnDescriptor{
+				ID: 4, Name: "crdb_internal_c3_c2_shard_8", Type: types.Int,
+				Hidden: true, Virtual: true, ComputeExpr: &shardExpr,
+			}),
+			sharded, makeTestIndex(3, "plain", 2),
+		))
+	}
+
+	desc := makeDesc("c3", "c2")
+	sharded, err := catalog.MustFindIndexByName(desc, "sharded")
+	require.NoError(t, err)
+	ids, err := sharded.ShardColumnIDs()
+	require.NoError(t, err)
+	require.Equal(t, descpb.ColumnIDs{3, 2}, ids)
+
+	for _, name := range []string{"foo_pkey", "plain"} {
+		idx, err := catalog.MustFindIndexByName(desc, name)
+		require.NoError(t, err)
+		ids, err := idx.ShardColumnIDs()
+		require.NoError(t, err)
+		require.Nil(t, ids, name)
+	}
+
+	// A sharded column name which doesn't resolve to a key column is a
+	// corruption, and is reported as such.
+	sharded, err = catalog.MustFindIndexByName(makeDesc("c3", "c4"), "sharded")
+	require.NoError(t, err)
+	_, err = sharded.ShardColumnIDs()
+	require.True(t, errors.HasAssertionFailure(err))
+	require.ErrorContains(t, err, `sharded column "c4" of index "sharded" is not a key column`)
+}
+
+func TestPartitioningNumLeafPartitions(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		partitioning catpb.PartitioningDescriptor
+		expected     int
+	}{
+		{
+			name:     "unpartitioned",
+			expected: 0,
+		},
+		{
+			name: "list",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List: []catpb.PartitioningDescriptor_List{
+					{Name: "p1"}, {Name: "p2"}, {Name: "p3"},
+				},
+			},
+			expected: 3,
+		},
+		{
+			name: "range",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				Range: []catpb.PartitioningDescriptor_Range{
+					{Name: "p1"}, {Name: "p2"},
+				},
+			},
+			expected: 2,
+		},
+		{
+			name: "nested",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List: []catpb.PartitioningDescriptor_List{
+					{
+						Name: "p1",
+						Subpartitioning: catpb.PartitioningDescriptor{
+							NumColumns: 1,
+							Range: []catpb.PartitioningDescriptor_Range{
+								{Name: "p1_1"}, {Name: "p1_2"}, {Name: "p1_3"},
+							},
+						},
+					},
+					{
+						Name: "p2",
+						Subpartitioning: catpb.PartitioningDescriptor{
+							NumColumns: 1,
+							List: []catpb.PartitioningDescriptor_List{
+								{Name: "p2_1"},
+								{
+									Name: "p2_2",
+									Subpartitioning: catpb.PartitioningDescriptor{
+										NumColumns: 1,
+										List: []catpb.PartitioningDescriptor_List{
+											{Name: "p2_2_1"}, {Name: "p2_2_2"},
+										},
+									},
+								},
+							},
+						},
+					},
+					{Name: "p3"},
+				},
+			},
+			expected: 7,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx := tabledesc.MakeIndexFromDescriptor(descpb.IndexDescriptor{
+				ID:           1,
+				Name:         "foo_pkey",
+				Partitioning: tc.partitioning,
+			}, 0 /* ordinal */)
+			require.Equal(t, tc.expected, idx.GetPartitioning().NumLeafPartitions())
+		})
+	}
+}
+
+func TestIndexAllPartitionNames(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		partitioning catpb.PartitioningDescriptor
+		expected     []string
+	}{
+		{
+			name: "unpartitioned",
+		},
+		{
+			name: "range",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				Range: []catpb.PartitioningDescriptor_Range{
+					{Name: "p1"}, {Name: "p2"},
+				},
+			},
+			expected: []string{"p1", "p2"},
+		},
+		{
+			name: "nested",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List: []catpb.PartitioningDescriptor_List{
+					{
+						Name: "p1",
+						Subpartitioning: catpb.PartitioningDescriptor{
+							NumColumns: 1,
+							Range: []catpb.PartitioningDescriptor_Range{
+								{Name: "p1_1"}, {Name: "p1_2"},
+							},
+						},
+					},
+					{
+						Name: "p2",
+						Subpartitioning: catpb.PartitioningDescriptor{
+							NumColumns: 1,
+							List: []catpb.PartitioningDescriptor_List{
+								{
+									Name: "p2_1",
+									Subpartitioning: catpb.PartitioningDescriptor{
+										NumColumns: 1,
+										List: []catpb.PartitioningDescriptor_List{
+											{Name: "p2_1_1"}, {Name: "p2_1_2"},
+										},
+									},
+								},
+								{Name: "p2_2"},
+							},
+						},
+					},
+					{Name: "p3"},
+				},
+			},
+			expected: []string{"p1", "p1_1", "p1_2", "p2", "p2_1", "p2_1_1", "p2_1_2", "p2_2", "p3"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx := tabledesc.MakeIndexFromDescriptor(descpb.IndexDescriptor{
+				ID:           1,
+				Name:         "foo_pkey",
+				Partitioning: tc.partitioning,
+			}, 0 /* ordinal */)
+			require.Equal(t, tc.expected, idx.AllPartitionNames())
+		})
+	}
+}
+
+func TestIndexPredicateColumnIDs(t *testing.T) {
+	partialIndex := func(id descpb.IndexID, name, predicate string) descpb.IndexDescriptor {
+		idx := makeTestIndex(id, name, 2)
+		idx.Predicate = predicate
+		return idx
+	}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int, types.Int),
+		partialIndex(2, "simple", "c3 > 0:::INT8"),
+		partialIndex(3, "compound", "((c4 > c1) OR (c3 IS NULL)) AND (c4 < 10:::INT8)"),
+		partialIndex(4, "constant", "true"),
+		partialIndex(5, "full", ""),
+		partialIndex(6, "unknown", "(c3 > 0:::INT8) AND (c5 = 1:::INT8)"),
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected descpb.ColumnIDs
+		err      bool
+	}{
+		{index: "simple", expected: descpb.ColumnIDs{3}},
+		{index: "compound", expected: descpb.ColumnIDs{1, 3, 4}},
+		{index: "constant", expected: nil},
+		{index: "full", expected: nil},
+		{index: "unknown", err: true},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			actual, err := idx.PredicateColumnIDs(desc)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestIndexIsPartitioningColumn(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		keyColumnIDs []descpb.ColumnID
+		partitioning catpb.PartitioningDescriptor
+		expected     map[descpb.ColumnID]bool
+	}{
+		{
+			name:         "unpartitioned",
+			keyColumnIDs: []descpb.ColumnID{2, 1},
+			expected:     map[descpb.ColumnID]bool{1: false, 2: false},
+		},
+		{
+			name:         "implicit",
+			keyColumnIDs: []descpb.ColumnID{3, 2, 1},
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns:         1,
+				NumImplicitColumns: 1,
+				List:               []catpb.PartitioningDescriptor_List{{Name: "us-east-1"}},
+			},
+			expected: map[descpb.ColumnID]bool{1: false, 2: false, 3: true, 4: false},
+		},
+		{
+			name:         "implicit and explicit",
+			keyColumnIDs: []descpb.ColumnID{3, 2, 1},
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns:         2,
+				NumImplicitColumns: 1,
+				Range:              []catpb.PartitioningDescriptor_Range{{Name: "p1"}},
+			},
+			expected: map[descpb.ColumnID]bool{1: false, 2: true, 3: true},
+		},
+		{
+			name:         "subpartitioned",
+			keyColumnIDs: []descpb.ColumnID{2, 1},
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List: []catpb.PartitioningDescriptor_List{{
+					Name: "p1",
+					Subpartitioning: catpb.PartitioningDescriptor{
+						NumColumns: 1,
+						List:       []catpb.PartitioningDescriptor_List{{Name: "p1_1"}},
+					},
+				}},
+			},
+			expected: map[descpb.ColumnID]bool{1: false, 2: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx := tabledesc.MakeIndexFromDescriptor(descpb.IndexDescriptor{
+				ID:           2,
+				Name:         "foo_idx",
+				KeyColumnIDs: tc.keyColumnIDs,
+				Partitioning: tc.partitioning,
+			}, 1 /* ordinal */)
+			for colID, expected := range tc.expected {
+				require.Equal(t, expected, idx.IsPartitioningColumn(colID), "column %d", colID)
+			}
+		})
+	}
+}
+
+func TestIndexImplicitPartitioningColumnCount(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		partitioning catpb.PartitioningDescriptor
+		expected     int
+	}{
+		{
+			name:     "unpartitioned",
+			expected: 0,
+		},
+		{
+			name: "explicit",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List:       []catpb.PartitioningDescriptor_List{{Name: "p1"}},
+			},
+			expected: 0,
+		},
+		{
+			name: "implicit",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns:         1,
+				NumImplicitColumns: 1,
+				List:               []catpb.PartitioningDescriptor_List{{Name: "p1"}},
+			},
+			expected: 1,
+		},
+		{
+			name: "partially implicit",
+			partitioning: catpb.PartitioningDescriptor{
+				NumColumns:         2,
+				NumImplicitColumns: 1,
+				List:               []catpb.PartitioningDescriptor_List{{Name: "p1"}},
+			},
+			expected: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx := tabledesc.MakeIndexFromDescriptor(descpb.IndexDescriptor{
+				ID:           1,
+				Name:         "foo_pkey",
+				Partitioning: tc.partitioning,
+			}, 0 /* ordinal */)
+			require.Equal(t, tc.expected, idx.ImplicitPartitioningColumnCount())
+			require.Equal(t, tc.expected, idx.GetPartitioning().NumImplicitColumns())
+		})
+	}
+}
+
+func TestIndexPartitionRegionNames(t *testing.T) {
+	regionList := func(names ...string) []catpb.PartitioningDescriptor_List {
+		ret := make([]catpb.PartitioningDescriptor_List, len(names))
+		for i, name := range names {
+			ret[i] = catpb.PartitioningDescriptor_List{Name: name}
+		}
+		return ret
+	}
+	partitionedIndex := func(
+		id descpb.IndexID, name string, partitioning catpb.PartitioningDescriptor, keyColIDs ...descpb.ColumnID,
+	) descpb.IndexDescriptor {
+		idx := makeTestIndex(id, name, keyColIDs...)
+		idx.Partitioning = partitioning
+		return idx
+	}
+	makeDesc := func(locality *catpb.LocalityConfig) catalog.TableDescriptor {
+		cols := makeTestColumns(types.Int, types.String, types.String)
+		cols[1].Name = "crdb_region"
+		desc := makeTestTable(cols,
+			partitionedIndex(2, "unpartitioned", catpb.PartitioningDescriptor{}, 3),
+			partitionedIndex(3, "explicit_list", catpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List:       regionList("p1", "p2"),
+			}, 3),
+			// PARTITION ALL BY LIST on a column other than the region column.
+			partitionedIndex(4, "implicit_list_non_region", catpb.PartitioningDescriptor{
+				NumColumns:         1,
+				NumImplicitColumns: 1,
+				List:               regionList("p1", "p2"),
+			}, 3, 2),
+			partitionedIndex(5, "implicit_range", catpb.PartitioningDescriptor{
+				NumColumns:         1,
+				NumImplicitColumns: 1,
+				Range:              []catpb.PartitioningDescriptor_Range{{Name: "p1"}},
+			}, 2, 3),
+			partitionedIndex(6, "subpartitioned", catpb.PartitioningDescriptor{
+				NumColumns:         1,
+				NumImplicitColumns: 1,
+				List: []catpb.PartitioningDescriptor_List{{
+					Name: "us-east-1",
+					Subpartitioning: catpb.PartitioningDescriptor{
+						NumColumns: 1,
+						List:       regionList("p1"),
+					},
+				}},
+			}, 2, 3),
+		)
+		setTestPrimaryKey(desc, 2, 1)
+		desc.PrimaryIndex.Partitioning = catpb.PartitioningDescriptor{
+			NumColumns:         1,
+			NumImplicitColumns: 1,
+			List:               regionList("ap-southeast-2", "ca-central-1", "us-east-1"),
+		}
+		desc.LocalityConfig = locality
+		return buildTestTable(desc)
+	}
+	rbr := makeDesc(&catpb.LocalityConfig{
+		Locality: &catpb.LocalityConfig_RegionalByRow_{
+			RegionalByRow: &catpb.LocalityConfig_RegionalByRow{},
+		},
+	})
+	// The same partitioning, but on a table which isn't REGIONAL BY ROW.
+	plain := makeDesc(nil /* locality */)
+
+	for _, tc := range []struct {
+		name     string
+		desc     catalog.TableDescriptor
+		index    string
+		expected []string
+	}{
+		{
+			name: "regional by row", desc: rbr, index: "foo_pkey",
+			expected: []string{"ap-southeast-2", "ca-central-1", "us-east-1"},
+		},
+		{name: "not regional by row", desc: plain, index: "foo_pkey"},
+		{name: "unpartitioned", desc: rbr, index: "unpartitioned"},
+		{name: "explicit list", desc: rbr, index: "explicit_list"},
+		{name: "implicit list on non-region column", desc: rbr, index: "implicit_list_non_region"},
+		{name: "implicit range", desc: rbr, index: "implicit_range"},
+		{name: "subpartitioned", desc: rbr, index: "subpartitioned"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(tc.desc, tc.index)
+			require.NoError(t, err)
+			names, err := idx.PartitionRegionNames(tc.desc)
+			if tc.expected == nil {
+				require.EqualError(t, err, fmt.Sprintf("index %q is not partitioned by region", tc.index))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestIndexIsRegionalByRowPartitioned(t *testing.T) {
+	regionPartitioning := catpb.PartitioningDescriptor{
+		NumColumns:         1,
+		NumImplicitColumns: 1,
+		List:               []catpb.PartitioningDescriptor_List{{Name: "us-east-1"}},
+	}
+	makeDesc := func(locality *catpb.LocalityConfig) catalog.TableDescriptor {
+		cols := makeTestColumns(types.Int, types.String, types.Int)
+		cols[1].Name = "crdb_region"
+		c3Region := makeTestIndex(2, "c3_region", 2, 3)
+		c3Region.Partitioning = regionPartitioning
+		c3Explicit := makeTestIndex(3, "c3_explicit", 3)
+		c3Explicit.KeySuffixColumnIDs = []descpb.ColumnID{2, 1}
+		c3Explicit.Partitioning = catpb.PartitioningDescriptor{
+			NumColumns: 1,
+			List:       []catpb.PartitioningDescriptor_List{{Name: "p1"}},
+		}
+		c3Implicit := makeTestIndex(4, "c3_implicit", 3, 2)
+		c3Implicit.Partitioning = catpb.PartitioningDescriptor{
+			NumColumns:         1,
+			NumImplicitColumns: 1,
+			List:               []catpb.PartitioningDescriptor_List{{Name: "p1"}},
+		}
+		desc := makeTestTable(cols, c3Region, c3Explicit, c3Implicit)
+		setTestPrimaryKey(desc, 2, 1)
+		desc.PrimaryIndex.Partitioning = regionPartitioning
+		desc.LocalityConfig = locality
+		return buildTestTable(desc)
+	}
+	rbr := makeDesc(&catpb.LocalityConfig{
+		Locality: &catpb.LocalityConfig_RegionalByRow_{
+			RegionalByRow: &catpb.LocalityConfig_RegionalByRow{},
+		},
+	})
+	// The same partitioning, but on a table which isn't REGIONAL BY ROW.
+	plain := makeDesc(nil /* locality */)
+
+	for _, tc := range []struct {
+		index       string
+		expectedRBR bool
+	}{
+		{index: "foo_pkey", expectedRBR: true},
+		{index: "c3_region", expectedRBR: true},
+		// Explicitly partitioned by a column other than the region column.
+		{index: "c3_explicit", expectedRBR: false},
+		// Implicitly partitioned by a column other than the region column.
+		{index: "c3_implicit", expectedRBR: false},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(rbr, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRBR, idx.IsRegionalByRowPartitioned(rbr))
+			idx, err = catalog.MustFindIndexByName(plain, tc.index)
+			require.NoError(t, err)
+			require.False(t, idx.IsRegionalByRowPartitioned(plain))
+		})
+	}
+}
+
+func TestIndexCanBeOriginForColumns(t *testing.T) {
+	secondaryIndex := func(id descpb.IndexID, name string, predicate string) descpb.IndexDescriptor {
+		idx := makeTestIndex(id, name, 2, 3)
+		idx.Predicate = predicate
+		idx.Version = descpb.LatestIndexDescriptorVersion
+		return idx
+	}
+	indexMutation := func(
+		idx descpb.IndexDescriptor, state descpb.DescriptorMutation_State,
+	) descpb.DescriptorMutation {
+		return descpb.DescriptorMutation{
+			Descriptor_: &descpb.DescriptorMutation_Index{Index: &idx},
+			State:       state,
+			Direction:   descpb.DescriptorMutation_ADD,
+			MutationID:  1,
+		}
+	}
+	tableDesc := makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int),
+		secondaryIndex(2, "public", ""),
+		secondaryIndex(3, "partial", "c3 > 0:::INT8"),
+	)
+	tableDesc.Mutations = []descpb.DescriptorMutation{
+		indexMutation(secondaryIndex(4, "write_only", ""), descpb.DescriptorMutation_WRITE_ONLY),
+		indexMutation(secondaryIndex(5, "delete_only", ""), descpb.DescriptorMutation_DELETE_ONLY),
+	}
+	desc := buildTestTable(tableDesc)
+
+	for _, tc := range []struct {
+		index    string
+		colIDs   descpb.ColumnIDs
+		expected bool
+	}{
+		{index: "public", colIDs: descpb.ColumnIDs{2}, expected: true},
+		{index: "public", colIDs: descpb.ColumnIDs{2, 3}, expected: true},
+		{index: "public", colIDs: descpb.ColumnIDs{3}, expected: false},
+		{index: "public", colIDs: descpb.ColumnIDs{2, 3, 1}, expected: false},
+		{index: "partial", colIDs: descpb.ColumnIDs{2}, expected: false},
+		{index: "write_only", colIDs: descpb.ColumnIDs{2}, expected: true},
+		{index: "delete_only", colIDs: descpb.ColumnIDs{2}, expected: false},
+		{index: "foo_pkey", colIDs: descpb.ColumnIDs{1}, expected: true},
+	} {
+		t.Run(fmt.Sprintf("%s/%v", tc.index, tc.colIDs), func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.CanBeOriginForColumns(tc.colIDs))
+		})
+	}
+}
+
+func TestIndexIsValidOriginIndex(t *testing.T) {
+	expected := map[string]bool{
+		"exact":        true,
+		"prefix":       true,
+		"not_a_prefix": false,
+		"permutation":  false,
+		"longer":       false,
+	}
+	outboundFK := func(name string, colIDs ...descpb.ColumnID) descpb.ForeignKeyConstraint {
+		return descpb.ForeignKeyConstraint{
+			OriginTableID:       2,
+			OriginColumnIDs:     colIDs,
+			ReferencedTableID:   3,
+			ReferencedColumnIDs: colIDs,
+			Name:                name,
+			Validity:            descpb.ConstraintValidity_Validated,
+		}
+	}
+	c2c3 := makeTestIndex(2, "c2_c3", 2, 3)
+	c2c3.Version = descpb.LatestIndexDescriptorVersion
+	tableDesc := makeTestTable(makeTestColumns(types.Int, types.Int, types.Int), c2c3)
+	tableDesc.OutboundFKs = []descpb.ForeignKeyConstraint{
+		outboundFK("exact", 2, 3),
+		outboundFK("prefix", 2),
+		outboundFK("not_a_prefix", 3),
+		outboundFK("permutation", 3, 2),
+		outboundFK("longer", 2, 3, 1),
+	}
+	desc := buildTestTable(tableDesc)
+	idx, err := catalog.MustFindIndexByName(desc, "c2_c3")
+	require.NoError(t, err)
+
+	fks := desc.OutboundForeignKeys()
+	require.Len(t, fks, len(expected))
+	for _, fk := range fks {
+		t.Run(fk.GetName(), func(t *testing.T) {
+			require.Equal(t, expected[fk.GetName()], idx.IsValidOriginIndex(fk))
+		})
+	}
+}
+
+func TestIndexEstimatedRowWidth(t *testing.T) {
+	wide := makeTestIndex(3, "wide", 3)
+	wide.StoreColumnIDs = []descpb.ColumnID{4}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int4, types.String, types.MakeVarChar(10)),
+		makeTestIndex(2, "narrow", 2), wide,
+	))
+
+	width := func(name string) int {
+		idx, err := catalog.MustFindIndexByName(desc, name)
+		require.NoError(t, err)
+		return idx.EstimatedRowWidth(desc)
+	}
+	narrow, wide, primary := width("narrow"), width("wide"), width("foo_pkey")
+	// INT4 + INT8.
+	require.Equal(t, 12, narrow)
+	// STRING + INT8 + VARCHAR(10).
+	require.Equal(t, 112, wide)
+	// INT8 + INT4 + STRING + VARCHAR(10).
+	require.Equal(t, 116, primary)
+	require.Less(t, narrow, wide)
+	require.Less(t, wide, primary)
+}
+
+func TestIndexCoversAllColumns(t *testing.T) {
+	virtualExpr := "c2 + c3"
+	cols := makeTestColumns(types.Int, types.Int, types.Int, types.Int)
+	cols[3].Virtual = true
+	cols[3].ComputeExpr = &virtualExpr
+	storingAll := makeTestIndex(2, "storing_all", 2)
+	storingAll.StoreColumnIDs = []descpb.ColumnID{3}
+	onVirtual := makeTestIndex(4, "on_virtual", 4)
+	onVirtual.StoreColumnIDs = []descpb.ColumnID{3}
+	desc := buildTestTable(makeTestTable(
+		cols, storingAll, makeTestIndex(3, "storing_some", 2), onVirtual,
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected bool
+	}{
+		{index: "foo_pkey", expected: true},
+		{index: "storing_all", expected: true},
+		{index: "storing_some", expected: false},
+		{index: "on_virtual", expected: false},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.CoversAllColumns(desc))
+		})
+	}
+}
+
+func TestIndexSpanForKeyPrefix(t *testing.T) {
+	c2c3 := makeTestIndex(2, "c2_c3", 2, 3)
+	c2c3.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.String), c2c3,
+	))
+	idx, err := catalog.MustFindIndexByName(desc, "c2_c3")
+	require.NoError(t, err)
+	codec := keys.SystemSQLCodec
+
+	encode := func(key roachpb.Key, val tree.Datum, dir encoding.Direction) roachpb.Key {
+		key, err := keyside.Encode(key, val, dir)
+		require.NoError(t, err)
+		return key
+	}
+	indexPrefix := roachpb.Key(codec.IndexPrefix(2, 2))
+	oneCol := encode(indexPrefix.Clone(), tree.NewDInt(5), encoding.Ascending)
+	twoCols := encode(oneCol.Clone(), tree.NewDString("x"), encoding.Descending)
+	nullCol := encode(indexPrefix.Clone(), tree.DNull, encoding.Ascending)
+
+	for _, tc := range []struct {
+		name     string
+		prefix   tree.Datums
+		expected roachpb.Key
+		err      string
+	}{
+		{name: "empty", prefix: nil, expected: indexPrefix},
+		{name: "single column", prefix: tree.Datums{tree.NewDInt(5)}, expected: oneCol},
+		{
+			name:     "multiple columns",
+			prefix:   tree.Datums{tree.NewDInt(5), tree.NewDString("x")},
+			expected: twoCols,
+		},
+		{name: "null", prefix: tree.Datums{tree.DNull}, expected: nullCol},
+		{
+			name:   "too many values",
+			prefix: tree.Datums{tree.NewDInt(5), tree.NewDString("x"), tree.NewDInt(1)},
+			err:    `index "c2_c3" has 2 key columns, got 3 prefix values`,
+		},
+		{
+			name:   "type mismatch",
+			prefix: tree.Datums{tree.NewDString("x")},
+			err:    `doesn't match type INT8 of column "c2"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			span, err := idx.SpanForKeyPrefix(codec, desc, tc.prefix)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, span.Key)
+			require.Equal(t, tc.expected.PrefixEnd(), span.EndKey)
+		})
+	}
+}
+
+func TestIndexRedundantStoredColumnIDs(t *testing.T) {
+	noRedundancy := makeTestIndex(2, "no_redundancy", 2)
+	noRedundancy.StoreColumnIDs = []descpb.ColumnID{3}
+	redundant := makeTestIndex(3, "redundant", 2)
+	redundant.StoreColumnIDs = []descpb.ColumnID{1, 2, 3}
+	redundant.Version = descpb.SecondaryIndexFamilyFormatVersion
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int), noRedundancy, redundant,
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected catalog.TableColSet
+	}{
+		{index: "foo_pkey", expected: catalog.TableColSet{}},
+		{index: "no_redundancy", expected: catalog.TableColSet{}},
+		{index: "redundant", expected: catalog.MakeTableColSet(1, 2)},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected.Ordered(), idx.RedundantStoredColumnIDs().Ordered())
+		})
+	}
+}
+
+func TestIndexExpressionColumnExprs(t *testing.T) {
+	lowerExpr, sumExpr := "lower(c2)", "c1 + c3"
+	multiExpr := makeTestIndex(2, "multi_expr", 5, 3, 4)
+	multiExpr.KeyColumnDirections[2] = catenumpb.IndexColumn_DESC
+	desc := buildTestTable(makeTestTable(
+		append(makeTestColumns(types.Int, types.String, types.Int),
+			descpb.ColumnDescriptor{ID: 4, Name: "crdb_internal_idx_expr", Type: types.String,
+				Virtual: true, Inaccessible: true, ComputeExpr: &lowerExpr},
+			descpb.ColumnDescriptor{ID: 5, Name: "crdb_internal_idx_expr_1", Type: types.Int,
+				Virtual: true, Inaccessible: true, ComputeExpr: &sumExpr},
+		),
+		multiExpr, makeTestIndex(3, "plain", 2),
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected []string
+	}{
+		{index: "multi_expr", expected: []string{"c1 + c3", "lower(c2)"}},
+		{index: "plain", expected: nil},
+		{index: "foo_pkey", expected: nil},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			exprs, err := idx.ExpressionColumnExprs(desc)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, exprs)
+		})
+	}
+}
+
+func TestIndexHasStoredColumn(t *testing.T) {
+	c2StoringC3 := makeTestIndex(2, "c2_storing_c3", 2)
+	c2StoringC3.StoreColumnIDs = []descpb.ColumnID{3}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int), c2StoringC3,
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected map[descpb.ColumnID]bool
+	}{
+		{index: "foo_pkey", expected: map[descpb.ColumnID]bool{1: false, 2: true, 3: true}},
+		// Neither the key column c2 nor the key suffix column c1 are stored.
+		{index: "c2_storing_c3", expected: map[descpb.ColumnID]bool{1: false, 2: false, 3: true}},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			for colID, expected := range tc.expected {
+				require.Equal(t, expected, idx.HasStoredColumn(colID), "column %d", colID)
+			}
+		})
+	}
+}
+
+func TestIndexReadableColumnIDs(t *testing.T) {
+	virtualExpr := "c2 + c3"
+	cols := makeTestColumns(types.Int, types.Int, types.Int, types.Decimal, types.Int)
+	cols[4].Virtual = true
+	cols[4].ComputeExpr = &virtualExpr
+	covering := makeTestIndex(2, "covering", 4, 2)
+	covering.StoreColumnIDs = []descpb.ColumnID{3}
+	covering.CompositeColumnIDs = []descpb.ColumnID{4}
+	desc := buildTestTable(makeTestTable(
+		cols, covering, makeTestIndex(3, "non_covering", 2),
+	))
+
+	for _, tc := range []struct {
+		index    string
+		expected []descpb.ColumnID
+		covering bool
+	}{
+		{index: "foo_pkey", expected: []descpb.ColumnID{1, 2, 3, 4}, covering: true},
+		{index: "covering", expected: []descpb.ColumnID{1, 2, 3, 4}, covering: true},
+		{index: "non_covering", expected: []descpb.ColumnID{1, 2}, covering: false},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.ReadableColumnIDs(desc).Ordered())
+			require.Equal(t, tc.covering, idx.CoversAllColumns(desc))
+		})
+	}
+}
+
+func TestIndexInvertedColumnKeyTypeSafe(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		typ      descpb.IndexDescriptor_Type
+		expected *types.T
+	}{
+		{name: "forward", typ: descpb.IndexDescriptor_FORWARD},
+		{name: "inverted", typ: descpb.IndexDescriptor_INVERTED, expected: types.EncodedKey},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx := tabledesc.MakeIndexFromDescriptor(descpb.IndexDescriptor{
+				ID:           2,
+				Name:         "idx",
+				Type:         tc.typ,
+				KeyColumnIDs: []descpb.ColumnID{2},
+			}, 1 /* ordinal */)
+			typ, ok := idx.InvertedColumnKeyTypeSafe()
+			if tc.expected == nil {
+				require.False(t, ok)
+				require.Nil(t, typ)
+				require.Panics(t, func() { idx.InvertedColumnKeyType() })
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tc.expected, typ)
+			require.Equal(t, idx.InvertedColumnKeyType(), typ)
+		})
+	}
+}
+
+func TestIndexKeyColumnPosition(t *testing.T) {
+	c3c2 := makeTestIndex(2, "c3_c2", 3, 2)
+	c3c2.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	c3c2.StoreColumnIDs = []descpb.ColumnID{4}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int, types.Int), c3c2,
+	))
+
+	idx, err := catalog.MustFindIndexByName(desc, "c3_c2")
+	require.NoError(t, err)
+	require.Equal(t, 0, idx.KeyColumnPosition(3))
+	require.Equal(t, 1, idx.KeyColumnPosition(2))
+	// Key suffix and stored columns aren't key columns.
+	require.Equal(t, -1, idx.KeyColumnPosition(1))
+	require.Equal(t, -1, idx.KeyColumnPosition(4))
+	require.Equal(t, -1, idx.KeyColumnPosition(5))
+
+	pkey := desc.GetPrimaryIndex()
+	require.Equal(t, 0, pkey.KeyColumnPosition(1))
+	require.Equal(t, -1, pkey.KeyColumnPosition(2))
+}
+
+func TestIndexKeySuffixColumnNames(t *testing.T) {
+	c3 := makeTestIndex(2, "c3", 3)
+	c3.KeySuffixColumnIDs = []descpb.ColumnID{2, 1}
+	c3c1 := makeTestIndex(3, "c3_c1", 3, 1)
+	c3c1.KeySuffixColumnIDs = []descpb.ColumnID{2}
+	c3Key := c3
+	c3Key.ID, c3Key.Name, c3Key.Unique = 4, "c3_key", true
+	unknown := makeTestIndex(5, "unknown", 3)
+	unknown.KeySuffixColumnIDs = []descpb.ColumnID{2, 4}
+	tableDesc := makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int), c3, c3c1, c3Key, unknown,
+	)
+	setTestPrimaryKey(tableDesc, 2, 1)
+	desc := buildTestTable(tableDesc)
+
+	for _, tc := range []struct {
+		index    string
+		expected []string
+	}{
+		{index: "foo_pkey"},
+		{index: "c3", expected: []string{"c2", "c1"}},
+		// Primary key columns already in the key aren't part of the suffix.
+		{index: "c3_c1", expected: []string{"c2"}},
+		{index: "c3_key", expected: []string{"c2", "c1"}},
+		{index: "unknown", expected: []string{"c2", "[4]"}},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.KeySuffixColumnNames(desc))
+		})
+	}
+}
+
+func TestIndexDirectionForKeyColumn(t *testing.T) {
+	c2Desc := makeTestIndex(2, "c2_desc", 2)
+	c2Desc.KeyColumnDirections[0] = catenumpb.IndexColumn_DESC
+	c2Desc.StoreColumnIDs = []descpb.ColumnID{3}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int), c2Desc,
+	))
+
+	idx, err := catalog.MustFindIndexByName(desc, "c2_desc")
+	require.NoError(t, err)
+	dir, ok := idx.DirectionForKeyColumn(2)
+	require.True(t, ok)
+	require.Equal(t, catenumpb.IndexColumn_DESC, dir)
+	// Key suffix and stored columns aren't key columns.
+	for _, colID := range []descpb.ColumnID{1, 3} {
+		_, ok := idx.DirectionForKeyColumn(colID)
+		require.False(t, ok, "column %d", colID)
+	}
+
+	dir, ok = desc.GetPrimaryIndex().DirectionForKeyColumn(1)
+	require.True(t, ok)
+	require.Equal(t, catenumpb.IndexColumn_ASC, dir)
+}
+
+func TestIndexKeyColumnIDToPositionMap(t *testing.T) {
+	c4c3 := makeTestIndex(2, "c4_c3", 4, 3)
+	c4c3.KeyColumnDirections[1] = catenumpb.IndexColumn_DESC
+	c4c3.KeySuffixColumnIDs = []descpb.ColumnID{2, 1}
+	tableDesc := makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.Int, types.Int), c4c3,
+	)
+	setTestPrimaryKey(tableDesc, 2, 1)
+	desc := buildTestTable(tableDesc)
+
+	for _, tc := range []struct {
+		index    string
+		expected map[descpb.ColumnID]int
+	}{
+		{index: "foo_pkey", expected: map[descpb.ColumnID]int{2: 0, 1: 1}},
+		// Key suffix columns aren't key columns.
+		{index: "c4_c3", expected: map[descpb.ColumnID]int{4: 0, 3: 1}},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			m := idx.KeyColumnIDToPositionMap()
+			require.Equal(t, len(tc.expected), m.Len())
+			for colID, pos := range tc.expected {
+				actual, ok := m.Get(colID)
+				require.True(t, ok, "column %d", colID)
+				require.Equal(t, pos, actual, "column %d", colID)
+			}
+		})
+	}
+}
+
+func TestIndexPredicateIsImmutable(t *testing.T) {
+	partialIndex := func(id descpb.IndexID, name, predicate string) descpb.IndexDescriptor {
+		idx := makeTestIndex(id, name, 2)
+		idx.Predicate = predicate
+		return idx
+	}
+	desc := buildTestTable(makeTestTable(
+		makeTestColumns(types.Int, types.Int, types.TimestampTZ),
+		partialIndex(2, "full", ""),
+		partialIndex(3, "immutable", "c2 > 0:::INT8"),
+		partialIndex(4, "stable", "c3 > now()"),
+		partialIndex(5, "volatile", "random() > 0.5:::FLOAT8"),
+		partialIndex(6, "unknown", "c4 = 1:::INT8"),
+	))
+
+	ctx := context.Background()
+	semaCtx := tree.MakeSemaContext(nil /* resolver */)
+	for _, tc := range []struct {
+		index    string
+		expected bool
+		err      bool
+	}{
+		{index: "full", expected: true},
+		{index: "immutable", expected: true},
+		{index: "stable", expected: false},
+		{index: "volatile", expected: false},
+		{index: "unknown", err: true},
+	} {
+		t.Run(tc.index, func(t *testing.T) {
+			idx, err := catalog.MustFindIndexByName(desc, tc.index)
+			require.NoError(t, err)
+			actual, err := idx.PredicateIsImmutable(ctx, &semaCtx, desc)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
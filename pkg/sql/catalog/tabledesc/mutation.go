@@ -242,6 +242,16 @@ func (m mutation) AsIndex() catalog.Index {
 	return m.index
 }
 
+// Column implements the catalog.Mutation interface.
+func (m mutation) Column() (catalog.Column, bool) {
+	return m.column, m.column != nil
+}
+
+// Index implements the catalog.Mutation interface.
+func (m mutation) Index() (catalog.Index, bool) {
+	return m.index, m.index != nil
+}
+
 // AsConstraintWithoutIndex implements the catalog.Mutation interface.
 func (m mutation) AsConstraintWithoutIndex() catalog.WithoutIndexConstraint {
 	if m.check != nil {
@@ -349,6 +359,7 @@ func newMutationCache(desc *descpb.TableDescriptor) *mutationCache {
 				maybeMutation: mm,
 				desc:          pb,
 				ordinal:       len(desc.Columns) + len(columns),
+				onUpdateExpr:  newOnUpdateExprCache(pb),
 			})
 			backingStructs[i].column = &columns[len(columns)-1]
 		} else if pb := m.GetIndex(); pb != nil {
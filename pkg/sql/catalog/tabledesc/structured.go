@@ -97,28 +97,7 @@ func (desc *wrapper) GetParentSchemaID() descpb.ID {
 
 // IndexKeysPerRow implements the TableDescriptor interface.
 func (desc *wrapper) IndexKeysPerRow(idx catalog.Index) int {
-	if desc.PrimaryIndex.ID == idx.GetID() || idx.GetEncodingType() == catenumpb.PrimaryIndexEncoding {
-		return len(desc.Families)
-	}
-	if idx.NumSecondaryStoredColumns() == 0 || len(desc.Families) == 1 {
-		return 1
-	}
-	// Calculate the number of column families used by the secondary index. We
-	// only need to look at the stored columns because column families are only
-	// applicable to the value part of the KV.
-	//
-	// 0th family is always present.
-	numUsedFamilies := 1
-	storedColumnIDs := idx.CollectSecondaryStoredColumnIDs()
-	for _, family := range desc.Families[1:] {
-		for _, columnID := range family.ColumnIDs {
-			if storedColumnIDs.Contains(columnID) {
-				numUsedFamilies++
-				break
-			}
-		}
-	}
-	return numUsedFamilies
+	return len(catalog.IndexFamilyIDs(desc, idx))
 }
 
 // BuildIndexName returns an index name that is not equal to any
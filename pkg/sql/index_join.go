@@ -17,6 +17,11 @@ import (
 // of a table. The input to an indexJoinNode is the result of scanning a
 // non-covering index (potentially processed through other operations like
 // filtering, sorting, limiting).
+//
+// indexJoinNode is never executed in local mode; it is always planned as a
+// joinReader or a ColIndexJoin. When the kvstreamer.Streamer is used, those
+// perform the lookups for a batch of input rows concurrently, and only
+// preserve the input ordering when it needs to be maintained.
 type indexJoinNode struct {
 	input planNode
 
@@ -47,7 +52,25 @@ func (n *indexJoinNode) Values() tree.Datums {
 	panic("indexJoinNode cannot be run in local mode")
 }
 
+// Close implements the planNode interface. Since indexJoinNode is never run
+// in local mode, it neither buffers input keys nor reserves memory: both are
+// owned by the processors which execute the index join (the joinReader and the
+// ColIndexJoin), which release them when closed, including when the query is
+// canceled mid-fetch. Only the child nodes need to be closed here.
 func (n *indexJoinNode) Close(ctx context.Context) {
 	n.input.Close(ctx)
 	n.table.Close(ctx)
 }
+
+// InputKeyColumnNames returns the names of the key columns of the primary
+// index of desc, in key order. Since keyCols holds the input ordinal of each
+// of these columns in the same order, the i-th name is that of the primary key
+// column which the input column at ordinal keyCols[i] is joined against.
+func (n *indexJoinNode) InputKeyColumnNames(desc catalog.TableDescriptor) []string {
+	pk := desc.GetPrimaryIndex()
+	names := make([]string, pk.NumKeyColumns())
+	for i := range names {
+		names[i] = pk.GetKeyColumnName(i)
+	}
+	return names
+}
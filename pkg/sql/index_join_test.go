@@ -0,0 +1,46 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexJoinInputKeyColumnNames(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := tabledesc.NewBuilder(&descpb.TableDescriptor{
+		ID:   2,
+		Name: "foo",
+		Columns: []descpb.ColumnDescriptor{
+			{ID: 1, Name: "a"},
+			{ID: 2, Name: "b"},
+			{ID: 3, Name: "c"},
+		},
+		PrimaryIndex: descpb.IndexDescriptor{
+			ID: 1, Name: "foo_pkey", KeyColumnIDs: []descpb.ColumnID{3, 1}, KeyColumnNames: []string{"c", "a"},
+			KeyColumnDirections: []catenumpb.IndexColumn_Direction{catenumpb.IndexColumn_ASC, catenumpb.IndexColumn_DESC},
+			StoreColumnIDs:      []descpb.ColumnID{2},
+			StoreColumnNames:    []string{"b"},
+			EncodingType:        catenumpb.PrimaryIndexEncoding,
+		},
+	}).BuildImmutableTable()
+
+	// The input produces (b, a, c), so the primary key columns (c, a) are found
+	// at input ordinals 2 and 1 respectively.
+	n := &indexJoinNode{keyCols: []int{2, 1}}
+	names := n.InputKeyColumnNames(desc)
+	require.Equal(t, []string{"c", "a"}, names)
+	require.Len(t, names, len(n.keyCols))
+}